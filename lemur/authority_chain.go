@@ -0,0 +1,122 @@
+package lemur
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"strconv"
+)
+
+// maxChainLength bounds how far an authority chain is followed.
+const maxChainLength = 10
+
+// chainLink is a certificate in an authority chain together with the Lemur
+// authority it belongs to, if any.
+type chainLink struct {
+	Cert        *x509.Certificate
+	AuthorityID string
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// findIssuer picks the candidate that issued cert, matching the authority key
+// identifier when both certificates carry one and the issuer name otherwise.
+// Only candidates whose key verifies the signature are accepted.
+func findIssuer(cert *x509.Certificate, candidates []chainLink) *chainLink {
+	for i, candidate := range candidates {
+		if bytes.Equal(candidate.Cert.Raw, cert.Raw) {
+			continue
+		}
+		if len(cert.AuthorityKeyId) > 0 && len(candidate.Cert.SubjectKeyId) > 0 {
+			if !bytes.Equal(cert.AuthorityKeyId, candidate.Cert.SubjectKeyId) {
+				continue
+			}
+		} else if !bytes.Equal(cert.RawIssuer, candidate.Cert.RawSubject) {
+			continue
+		}
+		if cert.CheckSignatureFrom(candidate.Cert) != nil {
+			continue
+		}
+		return &candidates[i]
+	}
+	return nil
+}
+
+// verifyChain checks that every certificate is signed by the one after it.
+func verifyChain(links []chainLink) error {
+	for i := 0; i < len(links)-1; i++ {
+		if err := links[i].Cert.CheckSignatureFrom(links[i+1].Cert); err != nil {
+			return fmt.Errorf("Certificate %q is not signed by %q: %s",
+				links[i].Cert.Subject.CommonName, links[i+1].Cert.Subject.CommonName, err)
+		}
+	}
+	return nil
+}
+
+// authorityChainLink fetches the certificate of a Lemur authority. It also
+// returns the parent authority ID, if Lemur knows it, and the chain Lemur
+// stores alongside the certificate.
+func authorityChainLink(authority map[string]interface{}, config Config) (chainLink, string, string, error) {
+	authorityID := int(authority["id"].(float64))
+	authorityCertificate, ok := authority["authorityCertificate"].(map[string]interface{})
+	if !ok {
+		return chainLink{}, "", "", fmt.Errorf("Authority %d has no authority certificate", authorityID)
+	}
+
+	certificateID := int(authorityCertificate["id"].(float64))
+	certificate, err := getCertificateByID(certificateID, config)
+	if err != nil {
+		return chainLink{}, "", "", err
+	}
+	if certificate == nil {
+		return chainLink{}, "", "", fmt.Errorf("Authority certificate %d of authority %d not found", certificateID, authorityID)
+	}
+
+	cert, err := parsePEMCertificate(stringValue(certificate, "body"))
+	if err != nil {
+		return chainLink{}, "", "", fmt.Errorf("Error parsing certificate of authority %d: %s", authorityID, err)
+	}
+
+	link := chainLink{Cert: cert, AuthorityID: strconv.Itoa(authorityID)}
+	return link, authorityParentID(authority, certificate), stringValue(certificate, "chain"), nil
+}
+
+// authorityChainCandidates collects the certificates an issuer can be
+// matched against when Lemur does not record the parent authority: every
+// authority certificate plus the chains stored with the walked certificates.
+func authorityChainCandidates(chains []string, config Config) ([]chainLink, error) {
+	candidates := []chainLink{}
+
+	items, err := listItems("authorities", "", config)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		_, body, err := authorityCertificatePEM(item, config)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := parsePEMCertificate(body)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, chainLink{
+			Cert:        cert,
+			AuthorityID: strconv.Itoa(int(item["id"].(float64))),
+		})
+	}
+
+	for _, chain := range chains {
+		certs, err := parsePEMCertificates(chain)
+		if err != nil {
+			continue
+		}
+		for _, cert := range certs {
+			candidates = append(candidates, chainLink{Cert: cert})
+		}
+	}
+
+	return candidates, nil
+}
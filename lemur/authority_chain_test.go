@@ -0,0 +1,80 @@
+package lemur
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func testAuthorityCertificate(t *testing.T, name string, serial int64, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if issuer == nil {
+		issuer, issuerKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return cert, key
+}
+
+func TestFindIssuer(t *testing.T) {
+	root, rootKey := testAuthorityCertificate(t, "Root CA", 1, nil, nil)
+	intermediate, intermediateKey := testAuthorityCertificate(t, "Intermediate CA", 2, root, rootKey)
+	issuing, _ := testAuthorityCertificate(t, "Issuing CA", 3, intermediate, intermediateKey)
+
+	// An unrelated authority reusing the intermediate's name must not match.
+	impostor, _ := testAuthorityCertificate(t, "Intermediate CA", 4, root, rootKey)
+
+	if !isSelfSigned(root) || isSelfSigned(intermediate) {
+		t.Fatal("unexpected self-signed detection")
+	}
+
+	candidates := []chainLink{
+		{Cert: impostor, AuthorityID: "4"},
+		{Cert: root, AuthorityID: "1"},
+		{Cert: intermediate, AuthorityID: "2"},
+	}
+
+	issuer := findIssuer(issuing, candidates)
+	if issuer == nil || issuer.AuthorityID != "2" {
+		t.Fatalf("expected the intermediate as issuer, got %v", issuer)
+	}
+	if issuer := findIssuer(root, candidates); issuer != nil {
+		t.Fatalf("expected no issuer for the root, got %v", issuer)
+	}
+
+	links := []chainLink{{Cert: issuing}, {Cert: intermediate}, {Cert: root}}
+	if err := verifyChain(links); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	links = []chainLink{{Cert: issuing}, {Cert: impostor}, {Cert: root}}
+	if err := verifyChain(links); err == nil {
+		t.Fatal("expected error for a chain with the wrong issuer")
+	}
+}
@@ -0,0 +1,338 @@
+package lemur
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// The exports in this file are built locally from the PEM data already
+// fetched from Lemur, so they never need an extra round trip.
+
+var (
+	oidPKCS7Data       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidPKCS7SignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidPBES2           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256  = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC       = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+const pkcs8Iterations = 10000
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      pkcs7ContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      []asn1.RawValue `asn1:"set"`
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	PRF            pkix.AlgorithmIdentifier
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type encryptedPrivateKeyInfo struct {
+	EncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedData       []byte
+}
+
+// certificateAndChain returns the leaf certificate followed by its chain.
+func certificateAndChain(d *schema.ResourceData) ([]*x509.Certificate, error) {
+	leaf, err := parsePEMCertificate(d.Get("pem_public_certificate").(string))
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing certificate %s: %s", d.Id(), err)
+	}
+
+	chain, err := parsePEMCertificates(d.Get("pem_chain").(string))
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing chain of certificate %s: %s", d.Id(), err)
+	}
+
+	return append([]*x509.Certificate{leaf}, chain...), nil
+}
+
+func exportCertificateDER(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
+	cert, err := parsePEMCertificate(d.Get("pem_public_certificate").(string))
+	if err != nil {
+		return "", "", fmt.Errorf("Error parsing certificate %d: %s", certificateID, err)
+	}
+
+	return base64.StdEncoding.EncodeToString(cert.Raw), "", nil
+}
+
+func exportCertificatePKCS7(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
+	certs, err := certificateAndChain(d)
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := encodePKCS7Certificates(certs)
+	if err != nil {
+		return "", "", fmt.Errorf("Error encoding certificate %d as PKCS#7: %s", certificateID, err)
+	}
+
+	return base64.StdEncoding.EncodeToString(data), "", nil
+}
+
+func exportCertificateFullChainPEM(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
+	certs, err := certificateAndChain(d)
+	if err != nil {
+		return "", "", err
+	}
+
+	return encodePEMCertificates(certs), "", nil
+}
+
+func exportCertificatePKCS8Encrypted(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
+	password := exportPassphrase(d, "pkcs8_passphrase")
+
+	key, err := parsePEMPrivateKey(d.Get("pem_private_certificate").(string))
+	if err != nil {
+		return "", "", fmt.Errorf("Error parsing private key of certificate %d: %s", certificateID, err)
+	}
+
+	data, err := encryptPKCS8PrivateKey(key, password)
+	if err != nil {
+		return "", "", fmt.Errorf("Error encrypting private key of certificate %d: %s", certificateID, err)
+	}
+
+	return data, password, nil
+}
+
+func encodePEMCertificates(certs []*x509.Certificate) string {
+	var buf bytes.Buffer
+	for _, cert := range certs {
+		pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return buf.String()
+}
+
+// encodePKCS7Certificates builds a degenerate, certificates-only PKCS#7
+// SignedData structure as used by .p7b files.
+func encodePKCS7Certificates(certs []*x509.Certificate) ([]byte, error) {
+	var raw []byte
+	for _, cert := range certs {
+		raw = append(raw, cert.Raw...)
+	}
+
+	signedData, err := asn1.Marshal(pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{},
+		ContentInfo:      pkcs7ContentInfo{ContentType: oidPKCS7Data},
+		Certificates: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      raw,
+		},
+		SignerInfos: []asn1.RawValue{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(pkcs7ContentInfo{
+		ContentType: oidPKCS7SignedData,
+		Content: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      signedData,
+		},
+	})
+}
+
+// parsePEMPrivateKey decodes the first private key found in a PEM blob,
+// whether it is PKCS#1, SEC 1 or PKCS#8 encoded.
+func parsePEMPrivateKey(data string) (crypto.PrivateKey, error) {
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("No PEM encoded private key found")
+		}
+
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			return x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(block.Bytes)
+		case "PRIVATE KEY":
+			return x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+
+		if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			return nil, fmt.Errorf("Unsupported private key type: %s", block.Type)
+		}
+	}
+}
+
+// encryptPKCS8PrivateKey encrypts a key as a PEM encoded PKCS#8
+// EncryptedPrivateKeyInfo using PBES2 with PBKDF2-SHA256 and AES-256-CBC.
+func encryptPKCS8PrivateKey(key crypto.PrivateKey, password string) (string, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+	default:
+		return "", fmt.Errorf("Unsupported private key type: %T", key)
+	}
+
+	plaintext, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(password), salt, pkcs8Iterations, 32, sha256.New))
+	if err != nil {
+		return "", err
+	}
+
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	plaintext = append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: pkcs8Iterations,
+		PRF: pkix.AlgorithmIdentifier{
+			Algorithm:  oidHMACWithSHA256,
+			Parameters: asn1.RawValue{Tag: asn1.TagNull},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		return "", err
+	}
+
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBKDF2,
+			Parameters: asn1.RawValue{FullBytes: kdfParams},
+		},
+		EncryptionScheme: pkix.AlgorithmIdentifier{
+			Algorithm:  oidAES256CBC,
+			Parameters: asn1.RawValue{FullBytes: ivParams},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	der, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		EncryptionAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBES2,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		EncryptedData: ciphertext,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der})), nil
+}
+
+// encodePEMPrivateKey re-encodes a private key as PKCS#8, or as the
+// traditional PKCS#1 (RSA) or SEC 1 (EC) structure for "pkcs1".
+func encodePEMPrivateKey(key crypto.PrivateKey, format string) (string, error) {
+	if format == "pkcs8" {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+	}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		der := x509.MarshalPKCS1PrivateKey(key)
+		return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: der})), nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})), nil
+	}
+
+	return "", fmt.Errorf("Unsupported private key type: %T", key)
+}
+
+// setKubernetesTLSData builds the data of a kubernetes.io/tls secret: the
+// leaf followed by its chain, the private key and the top of the chain as
+// CA. The key is left out whenever it must not be stored in plaintext.
+func setKubernetesTLSData(d *schema.ResourceData) error {
+	if d.Get("pem_public_certificate").(string) == "" {
+		return d.Set("kubernetes_tls_data", map[string]interface{}{})
+	}
+
+	certs, err := certificateAndChain(d)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"tls.crt": encodePEMCertificates(certs),
+	}
+	// Without a chain the top certificate is the leaf, which is no CA.
+	if len(certs) > 1 {
+		data["ca.crt"] = encodePEMCertificates(certs[len(certs)-1:])
+	}
+
+	privateCert := d.Get("pem_private_certificate").(string)
+	if privateCert != "" && d.Get("pgp_key").(string) == "" {
+		key, err := parsePEMPrivateKey(privateCert)
+		if err != nil {
+			return fmt.Errorf("Error parsing private key of certificate %s: %s", d.Id(), err)
+		}
+		data["tls.key"], err = encodePEMPrivateKey(key, d.Get("kubernetes_key_format").(string))
+		if err != nil {
+			return fmt.Errorf("Error encoding private key of certificate %s: %s", d.Id(), err)
+		}
+	}
+
+	return d.Set("kubernetes_tls_data", data)
+}
@@ -0,0 +1,230 @@
+package lemur
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func TestEncodePKCS7Certificates(t *testing.T) {
+	_, cert := testCertificatePEM(t, testCertificateTemplate())
+
+	der, err := encodePKCS7Certificates([]*x509.Certificate{cert, cert})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var contentInfo pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(der, &contentInfo); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !contentInfo.ContentType.Equal(oidPKCS7SignedData) {
+		t.Fatalf("unexpected content type: %s", contentInfo.ContentType)
+	}
+
+	var signedData pkcs7SignedData
+	if _, err := asn1.Unmarshal(contentInfo.Content.Bytes, &signedData); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	certs, err := x509.ParseCertificates(signedData.Certificates.Bytes)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(certs) != 2 || !certs[0].Equal(cert) {
+		t.Fatalf("unexpected certificates: %v", certs)
+	}
+}
+
+func TestEncodePEMCertificates(t *testing.T) {
+	leafPEM, leaf := testCertificatePEM(t, testCertificateTemplate())
+	chainPEM, chain := testCertificatePEM(t, testCertificateTemplate())
+
+	fullChain := encodePEMCertificates([]*x509.Certificate{leaf, chain})
+	if fullChain != leafPEM+chainPEM {
+		t.Fatalf("unexpected full chain:\n%s", fullChain)
+	}
+	if strings.Count(fullChain, "BEGIN CERTIFICATE") != 2 {
+		t.Fatalf("expected two certificates:\n%s", fullChain)
+	}
+}
+
+func TestSetKubernetesTLSData(t *testing.T) {
+	root, rootKey := testAuthorityCertificate(t, "Root CA", 1, nil, nil)
+	intermediate, intermediateKey := testAuthorityCertificate(t, "Intermediate CA", 2, root, rootKey)
+	leaf, leafKey := testAuthorityCertificate(t, "example.com", 3, intermediate, intermediateKey)
+
+	for _, format := range []string{"pkcs1", "pkcs8"} {
+		d := resourceLemurCertificate().TestResourceData()
+		d.SetId("1")
+		d.Set("pem_public_certificate", testPEM(leaf))
+		d.Set("pem_chain", testPEM(intermediate)+testPEM(root))
+		d.Set("pem_private_certificate", testKeyPEM(t, leafKey))
+		d.Set("kubernetes_key_format", format)
+
+		if err := setKubernetesTLSData(d); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		data := d.Get("kubernetes_tls_data").(map[string]interface{})
+		if len(data) != 3 {
+			t.Fatalf("unexpected keys: %v", data)
+		}
+		if data["tls.crt"] != testPEM(leaf)+testPEM(intermediate)+testPEM(root) {
+			t.Errorf("unexpected tls.crt:\n%s", data["tls.crt"])
+		}
+		if data["ca.crt"] != testPEM(root) {
+			t.Errorf("unexpected ca.crt:\n%s", data["ca.crt"])
+		}
+
+		header := map[string]string{"pkcs1": "EC PRIVATE KEY", "pkcs8": "PRIVATE KEY"}[format]
+		if !strings.HasPrefix(data["tls.key"].(string), "-----BEGIN "+header+"-----") {
+			t.Errorf("%s: unexpected tls.key:\n%s", format, data["tls.key"])
+		}
+		if err := verifyPrivateKey(testPEM(leaf), data["tls.key"].(string)); err != nil {
+			t.Errorf("%s: err: %s", format, err)
+		}
+	}
+}
+
+func TestSetKubernetesTLSData_noChain(t *testing.T) {
+	leaf, _ := testAuthorityCertificate(t, "example.com", 1, nil, nil)
+
+	d := resourceLemurCertificate().TestResourceData()
+	d.SetId("1")
+	d.Set("pem_public_certificate", testPEM(leaf))
+
+	if err := setKubernetesTLSData(d); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	data := d.Get("kubernetes_tls_data").(map[string]interface{})
+	if data["tls.crt"] != testPEM(leaf) {
+		t.Errorf("unexpected tls.crt:\n%s", data["tls.crt"])
+	}
+	if v, ok := data["ca.crt"]; ok {
+		t.Errorf("expected no ca.crt without a chain, got:\n%s", v)
+	}
+}
+
+// testDecryptPKCS8 decrypts a PBES2 protected EncryptedPrivateKeyInfo
+// independently of how encryptPKCS8PrivateKey builds it.
+func testDecryptPKCS8(t *testing.T, data string, password string) interface{} {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil || block.Type != "ENCRYPTED PRIVATE KEY" {
+		t.Fatalf("expected an ENCRYPTED PRIVATE KEY block, got %q", data)
+	}
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !info.EncryptionAlgorithm.Algorithm.Equal(oidPBES2) {
+		t.Fatalf("unexpected algorithm: %s", info.EncryptionAlgorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.EncryptionAlgorithm.Parameters.FullBytes, &params); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) || !kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA256) {
+		t.Fatal("expected PBKDF2 with HMAC-SHA256")
+	}
+	if !params.EncryptionScheme.Algorithm.Equal(oidAES256CBC) {
+		t.Fatalf("unexpected cipher: %s", params.EncryptionScheme.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	key := pbkdf2.Key([]byte(password), kdfParams.Salt, kdfParams.IterationCount, 32, sha256.New)
+	aesBlock, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(info.EncryptedData)%aes.BlockSize != 0 {
+		t.Fatal("ciphertext is not a multiple of the block size")
+	}
+	plaintext := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(aesBlock, iv).CryptBlocks(plaintext, info.EncryptedData)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding < 1 || padding > aes.BlockSize || !bytes.Equal(plaintext[len(plaintext)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		t.Fatal("invalid padding, wrong password or broken encryption")
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(plaintext[:len(plaintext)-padding])
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return privateKey
+}
+
+func TestEncryptPKCS8PrivateKey(t *testing.T) {
+	key, _ := testLemurKey(t)
+
+	data, err := encryptPKCS8PrivateKey(key, "secret")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if decrypted := testDecryptPKCS8(t, data, "secret"); !reflect.DeepEqual(decrypted, key) {
+		t.Fatal("decrypted key does not match the original")
+	}
+
+	again, err := encryptPKCS8PrivateKey(key, "secret")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if again == data {
+		t.Fatal("expected a fresh salt and IV for every encryption")
+	}
+}
+
+func TestExportCertificatePKCS8Encrypted(t *testing.T) {
+	key, keyPEM := testLemurKey(t)
+
+	d := resourceLemurCertificate().TestResourceData()
+	d.Set("pem_private_certificate", keyPEM)
+	d.Set("pkcs8_passphrase", "configured")
+
+	data, passphrase, err := exportCertificatePKCS8Encrypted(1, d, Config{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if passphrase != "configured" {
+		t.Fatalf("expected the configured passphrase, got %q", passphrase)
+	}
+	if decrypted := testDecryptPKCS8(t, data, passphrase); !reflect.DeepEqual(decrypted, key) {
+		t.Fatal("decrypted key does not match the original")
+	}
+
+	d = resourceLemurCertificate().TestResourceData()
+	d.Set("pem_private_certificate", keyPEM)
+	data, passphrase, err = exportCertificatePKCS8Encrypted(1, d, Config{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if passphrase == "" {
+		t.Fatal("expected a generated passphrase")
+	}
+	testDecryptPKCS8(t, data, passphrase)
+
+	d.Set("pem_private_certificate", "")
+	if _, _, err := exportCertificatePKCS8Encrypted(1, d, Config{}); err == nil {
+		t.Fatal("expected error without a private key")
+	}
+}
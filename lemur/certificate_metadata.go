@@ -0,0 +1,176 @@
+package lemur
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// parsePEMCertificate decodes the first certificate found in a PEM blob.
+func parsePEMCertificate(data string) (*x509.Certificate, error) {
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("No PEM encoded certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// parsePEMCertificates decodes every certificate found in a PEM blob, in order.
+func parsePEMCertificates(data string) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return certs, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
+
+func certificateFingerprints(cert *x509.Certificate) (string, string) {
+	sha1Sum := sha1.Sum(cert.Raw)
+	sha256Sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sha1Sum[:]), hex.EncodeToString(sha256Sum[:])
+}
+
+// certificateKeyInfo returns the key size and Lemur's name for the key type,
+// e.g. RSA2048 or ECCPRIME256V1.
+func certificateKeyInfo(cert *x509.Certificate) (int, string) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		bits := key.N.BitLen()
+		return bits, fmt.Sprintf("RSA%d", bits)
+	case *ecdsa.PublicKey:
+		bits := key.Curve.Params().BitSize
+		switch key.Curve.Params().Name {
+		case "P-256":
+			return bits, "ECCPRIME256V1"
+		case "P-384":
+			return bits, "ECCSECP384R1"
+		case "P-521":
+			return bits, "ECCSECP521R1"
+		}
+		return bits, "ECC" + key.Curve.Params().Name
+	}
+	return 0, ""
+}
+
+func certificateSANNames(cert *x509.Certificate) []string {
+	names := []string{}
+	names = append(names, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+	return names
+}
+
+func formatCertificateTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func stringValue(m map[string]interface{}, key string) string {
+	if v, ok := m[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// setCertificateMetadata sets the computed attributes describing an issued
+// certificate. Values reported by Lemur are cross-checked against the
+// certificate itself, which wins whenever the two disagree.
+func setCertificateMetadata(d *schema.ResourceData, certificate map[string]interface{}, publicCert string) error {
+	serial := stringValue(certificate, "serial")
+	notBefore := stringValue(certificate, "notBefore")
+	notAfter := stringValue(certificate, "notAfter")
+	issuer := stringValue(certificate, "issuer")
+	keyType := stringValue(certificate, "keyType")
+
+	bits := 0
+	if v, ok := certificate["bits"].(float64); ok {
+		bits = int(v)
+	}
+
+	sanNames := []string{}
+	if domains, ok := certificate["domains"].([]interface{}); ok {
+		for _, domain := range domains {
+			if domain, ok := domain.(map[string]interface{}); ok {
+				sanNames = append(sanNames, stringValue(domain, "name"))
+			}
+		}
+	}
+
+	sha1Fingerprint := ""
+	sha256Fingerprint := ""
+
+	if publicCert != "" {
+		cert, err := parsePEMCertificate(publicCert)
+		if err != nil {
+			return fmt.Errorf("Error parsing certificate %s: %s", d.Id(), err)
+		}
+
+		if serial != "" && serial != cert.SerialNumber.String() {
+			return fmt.Errorf("Serial number reported by Lemur (%s) does not match certificate %s (%s)", serial, d.Id(), cert.SerialNumber.String())
+		}
+		serial = cert.SerialNumber.String()
+
+		parsedNotAfter := formatCertificateTime(cert.NotAfter)
+		if notAfter != "" {
+			if t, err := time.Parse(time.RFC3339, notAfter); err == nil && !t.Equal(cert.NotAfter) {
+				log.Printf("[WARN] notAfter reported by Lemur (%s) does not match certificate %s (%s)", notAfter, d.Id(), parsedNotAfter)
+			}
+		}
+		notBefore = formatCertificateTime(cert.NotBefore)
+		notAfter = parsedNotAfter
+
+		if issuer == "" {
+			issuer = cert.Issuer.CommonName
+		}
+
+		bits, keyType = certificateKeyInfo(cert)
+		sanNames = certificateSANNames(cert)
+		sha1Fingerprint, sha256Fingerprint = certificateFingerprints(cert)
+	}
+
+	d.Set("serial", serial)
+	d.Set("not_before", notBefore)
+	d.Set("not_after", notAfter)
+	d.Set("issuer", issuer)
+	d.Set("status", stringValue(certificate, "status"))
+	d.Set("bits", bits)
+	d.Set("key_type", strings.ToUpper(keyType))
+	d.Set("san_names", sanNames)
+	d.Set("sha1_fingerprint", sha1Fingerprint)
+	d.Set("sha256_fingerprint", sha256Fingerprint)
+	d.Set("lemur_name", stringValue(certificate, "name"))
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package lemur
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+func testCertificatePEM(t *testing.T, template *x509.Certificate) (string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), cert
+}
+
+func testExtension(t *testing.T, oid asn1.ObjectIdentifier, value string) pkix.Extension {
+	der, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return pkix.Extension{Id: oid, Value: der}
+}
+
+func testCertificateTemplate() *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(4242),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		Issuer:       pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		DNSNames:     []string{"example.com", "www.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+	}
+}
+
+func TestSetCertificateMetadata(t *testing.T) {
+	publicCert, cert := testCertificatePEM(t, testCertificateTemplate())
+
+	d := resourceLemurCertificate().TestResourceData()
+	d.SetId("1")
+
+	certificate := map[string]interface{}{
+		"name":   "example-com-20170101",
+		"serial": "4242",
+		"status": "valid",
+		"issuer": "ExampleCA",
+	}
+
+	if err := setCertificateMetadata(d, certificate, publicCert); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	sum := sha256.Sum256(cert.Raw)
+	expected := map[string]interface{}{
+		"serial":             "4242",
+		"not_before":         "2017-01-01T00:00:00Z",
+		"not_after":          "2018-01-01T00:00:00Z",
+		"issuer":             "ExampleCA",
+		"status":             "valid",
+		"bits":               256,
+		"key_type":           "ECCPRIME256V1",
+		"sha256_fingerprint": hex.EncodeToString(sum[:]),
+		"lemur_name":         "example-com-20170101",
+	}
+	for k, v := range expected {
+		if actual := d.Get(k); actual != v {
+			t.Errorf("%s: expected %v, got %v", k, v, actual)
+		}
+	}
+
+	sanNames := d.Get("san_names").([]interface{})
+	if len(sanNames) != 3 || sanNames[2] != "10.0.0.1" {
+		t.Errorf("unexpected san_names: %v", sanNames)
+	}
+}
+
+func TestSetCertificateMetadata_serialMismatch(t *testing.T) {
+	publicCert, _ := testCertificatePEM(t, testCertificateTemplate())
+
+	d := resourceLemurCertificate().TestResourceData()
+	d.SetId("1")
+
+	certificate := map[string]interface{}{
+		"serial": "1",
+	}
+
+	if err := setCertificateMetadata(d, certificate, publicCert); err == nil {
+		t.Fatal("expected error for mismatched serial")
+	}
+}
@@ -0,0 +1,108 @@
+package lemur
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// verifyCertificateChain checks that chain, in order, issued publicCert. An
+// empty chain is only valid for a self-signed certificate.
+func verifyCertificateChain(publicCert string, chain string) error {
+	cert, err := parsePEMCertificate(publicCert)
+	if err != nil {
+		return err
+	}
+	chainCerts, err := parsePEMCertificates(chain)
+	if err != nil {
+		return err
+	}
+
+	if len(chainCerts) == 0 {
+		if !isSelfSigned(cert) {
+			return fmt.Errorf("the chain is empty and %q is not self-signed", cert.Subject.CommonName)
+		}
+		return nil
+	}
+
+	links := []chainLink{{Cert: cert}}
+	for _, chainCert := range chainCerts {
+		links = append(links, chainLink{Cert: chainCert})
+	}
+	return verifyChain(links)
+}
+
+// verifyPrivateKey checks that privateKey belongs to publicCert.
+func verifyPrivateKey(publicCert string, privateKey string) error {
+	cert, err := parsePEMCertificate(publicCert)
+	if err != nil {
+		return err
+	}
+	key, err := parsePEMPrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	if !publicKeyMatches(cert.PublicKey, key) {
+		return fmt.Errorf("the private key does not match the public key of %q", cert.Subject.CommonName)
+	}
+	return nil
+}
+
+func publicKeyMatches(publicKey crypto.PublicKey, privateKey crypto.PrivateKey) bool {
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		pub, ok := publicKey.(*rsa.PublicKey)
+		return ok && pub.N.Cmp(key.N) == 0 && pub.E == key.E
+	case *ecdsa.PrivateKey:
+		pub, ok := publicKey.(*ecdsa.PublicKey)
+		return ok && pub.Curve == key.Curve && pub.X.Cmp(key.X) == 0 && pub.Y.Cmp(key.Y) == 0
+	}
+	return false
+}
+
+// setCertificateVerification sets chain_valid and key_matches from the PEM
+// data in d. With strict_verification a failed check is returned as an
+// error, otherwise it is only logged. key_matches is false when there is no
+// private key to check.
+func setCertificateVerification(d *schema.ResourceData) error {
+	publicCert := d.Get("pem_public_certificate").(string)
+	if publicCert == "" {
+		return nil
+	}
+	strict := d.Get("strict_verification").(bool)
+
+	chainErr := verifyCertificateChain(publicCert, d.Get("pem_chain").(string))
+	d.Set("chain_valid", chainErr == nil)
+	if chainErr != nil {
+		if strict {
+			return fmt.Errorf("Chain of certificate %s is invalid: %s", d.Id(), chainErr)
+		}
+		log.Printf("[WARN] Chain of certificate %s is invalid: %s", d.Id(), chainErr)
+	}
+
+	privateKey := d.Get("pem_private_certificate").(string)
+	if privateKey == "" {
+		// With pgp_key the key is only in plaintext right after it was
+		// fetched, so the result of that check is kept.
+		if pgpKey, _ := d.Get("pgp_key").(string); pgpKey == "" {
+			d.Set("key_matches", false)
+		}
+		return nil
+	}
+
+	keyErr := verifyPrivateKey(publicCert, privateKey)
+	d.Set("key_matches", keyErr == nil)
+	if keyErr != nil {
+		if strict {
+			return fmt.Errorf("Private key of certificate %s is invalid: %s", d.Id(), keyErr)
+		}
+		log.Printf("[WARN] Private key of certificate %s is invalid: %s", d.Id(), keyErr)
+	}
+
+	return nil
+}
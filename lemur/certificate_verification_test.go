@@ -0,0 +1,67 @@
+package lemur
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func testPEM(cert *x509.Certificate) string {
+	return encodePEMCertificates([]*x509.Certificate{cert})
+}
+
+func testKeyPEM(t *testing.T, key *ecdsa.PrivateKey) string {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+}
+
+func TestSetCertificateVerification(t *testing.T) {
+	root, rootKey := testAuthorityCertificate(t, "Root CA", 1, nil, nil)
+	intermediate, intermediateKey := testAuthorityCertificate(t, "Intermediate CA", 2, root, rootKey)
+	leaf, leafKey := testAuthorityCertificate(t, "example.com", 3, intermediate, intermediateKey)
+
+	cases := []struct {
+		chain      string
+		key        string
+		strict     bool
+		chainValid bool
+		keyMatches bool
+		err        bool
+	}{
+		{testPEM(intermediate) + testPEM(root), testKeyPEM(t, leafKey), false, true, true, false},
+		{testPEM(intermediate), testKeyPEM(t, leafKey), true, true, true, false},
+		{testPEM(root) + testPEM(intermediate), testKeyPEM(t, leafKey), false, false, true, false},
+		{testPEM(root) + testPEM(intermediate), testKeyPEM(t, leafKey), true, false, true, true},
+		{"", testKeyPEM(t, leafKey), false, false, true, false},
+		{testPEM(intermediate), testKeyPEM(t, rootKey), false, true, false, false},
+		{testPEM(intermediate), testKeyPEM(t, rootKey), true, true, false, true},
+		{testPEM(intermediate), "", true, true, false, false},
+	}
+
+	for i, tc := range cases {
+		d := resourceLemurCertificate().TestResourceData()
+		d.SetId("1")
+		d.Set("pem_public_certificate", testPEM(leaf))
+		d.Set("pem_chain", tc.chain)
+		d.Set("pem_private_certificate", tc.key)
+		d.Set("strict_verification", tc.strict)
+
+		err := setCertificateVerification(d)
+		if (err != nil) != tc.err {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		}
+		if err != nil {
+			continue
+		}
+		if d.Get("chain_valid").(bool) != tc.chainValid {
+			t.Errorf("%d: expected chain_valid %t", i, tc.chainValid)
+		}
+		if d.Get("key_matches").(bool) != tc.keyMatches {
+			t.Errorf("%d: expected key_matches %t", i, tc.keyMatches)
+		}
+	}
+}
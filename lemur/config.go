@@ -1,8 +1,32 @@
 package lemur
 
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
 type Config struct {
-	Host  string
-	Token string
+	Host   string
+	Token  string
+	Client *http.Client
+
+	ctx context.Context
+}
+
+// withTimeout returns a copy of the config whose requests are cancelled once
+// the timeout passes. Deadlines nest, so a Read called from Create never
+// outlives the Create timeout.
+func (c Config) withTimeout(timeout time.Duration) (Config, context.CancelFunc) {
+	parent := c.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	c.ctx = ctx
+
+	return c, cancel
 }
 
 type CreateCertificateRequest struct {
@@ -20,15 +44,30 @@ type CreateCertificateRequest struct {
 	Rotation           bool                              `json:"rotation"`
 	ValidityYears      int                               `json:"validityYears"`
 	Extensions         CreateCertificateExtensions       `json:"extensions,omitempty"`
+	DNSProvider        *CreateCertificateDNSProvider     `json:"dnsProvider,omitempty"`
+	RotationPolicy     *CreateCertificateRotationPolicy  `json:"rotationPolicy,omitempty"`
 }
 
 type CreateCertificateRequestAuthority struct {
 	Name string `json:"name"`
 }
 
+type CreateCertificateDNSProvider struct {
+	ID int `json:"id"`
+}
+
+type CreateCertificateRotationPolicy struct {
+	Name string `json:"name"`
+}
+
 type CreateCertificateExtensions struct {
-	SubAltNames      CreateCertificateAltNames         `json:"subAltNames,omitempty"`
-	ExtendedKeyUsage CreateCertificateExtendedKeyUsage `json:"extendedKeyUsage,omitempty"`
+	SubAltNames           CreateCertificateAltNames               `json:"subAltNames,omitempty"`
+	ExtendedKeyUsage      *CreateCertificateExtendedKeyUsage      `json:"extendedKeyUsage,omitempty"`
+	KeyUsage              *CreateCertificateKeyUsage              `json:"keyUsage,omitempty"`
+	BasicConstraints      *CreateCertificateBasicConstraints      `json:"basicConstraints,omitempty"`
+	CertificateInfoAccess *CreateCertificateInfoAccess            `json:"certificateInfoAccess,omitempty"`
+	CRLDistributionPoints *CreateCertificateCRLDistributionPoints `json:"crlDistributionPoints,omitempty"`
+	Custom                []CreateCertificateCustomExtension      `json:"custom,omitempty"`
 }
 
 type CreateCertificateAltNames struct {
@@ -43,4 +82,66 @@ type CreateCertificateNames struct {
 type CreateCertificateExtendedKeyUsage struct {
 	UseClientAuthentication bool `json:"useClientAuthentication"`
 	UseServerAuthentication bool `json:"useServerAuthentication"`
+	UseCodeSigning          bool `json:"useCodeSigning"`
+	UseEmailProtection      bool `json:"useEmailProtection"`
+	UseTimestamping         bool `json:"useTimestamping"`
+	UseOCSPSigning          bool `json:"useOcspSigning"`
+}
+
+type CreateCertificateKeyUsage struct {
+	UseDigitalSignature bool `json:"useDigitalSignature"`
+	UseNonRepudiation   bool `json:"useNonRepudiation"`
+	UseKeyEncipherment  bool `json:"useKeyEncipherment"`
+	UseDataEncipherment bool `json:"useDataEncipherment"`
+	UseKeyAgreement     bool `json:"useKeyAgreement"`
+	UseKeyCertSign      bool `json:"useKeyCertSign"`
+	UseCRLSign          bool `json:"useCRLSign"`
+	UseEncipherOnly     bool `json:"useEncipherOnly"`
+	UseDecipherOnly     bool `json:"useDecipherOnly"`
+}
+
+type CreateCertificateBasicConstraints struct {
+	CA         bool `json:"ca"`
+	PathLength *int `json:"pathLength,omitempty"`
+}
+
+type CreateCertificateInfoAccess struct {
+	IncludeAIA bool `json:"includeAia"`
+}
+
+type CreateCertificateCRLDistributionPoints struct {
+	IncludeCRLDP bool `json:"includeCrlDp"`
+}
+
+type CreateCertificateCustomExtension struct {
+	OID        string `json:"oid"`
+	Encoding   string `json:"encoding"`
+	Value      string `json:"value"`
+	IsCritical bool   `json:"isCritical"`
+}
+
+type CreateDNSProviderRequest struct {
+	Name         string                       `json:"name"`
+	Description  string                       `json:"description,omitempty"`
+	ProviderType CreateDNSProviderTypeRequest `json:"providerType"`
+}
+
+type CreateDNSProviderTypeRequest struct {
+	Name         string                    `json:"name"`
+	Requirements []CreateDNSProviderOption `json:"requirements"`
+}
+
+type CreateDNSProviderOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type RotationPolicyRequest struct {
+	Name string `json:"name"`
+	Days int    `json:"days"`
+}
+
+type RevokeCertificateRequest struct {
+	CRLReason string `json:"crlReason"`
+	Comments  string `json:"comments,omitempty"`
 }
@@ -0,0 +1,188 @@
+package lemur
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceLemurAuthorities() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceLemurAuthoritiesRead,
+
+		Schema: map[string]*schema.Schema{
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRegexp,
+			},
+			"plugin": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"owner": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			// A string so that leaving it unset lists both active and
+			// inactive authorities.
+			"active": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateBool,
+			},
+
+			"ids": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"names": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"authorities": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"owner": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"plugin": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"active": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"authority_certificate_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"pem": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceLemurAuthoritiesRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(Config)
+
+	filter := ""
+	if v, ok := d.GetOk("owner"); ok {
+		filter = "owner;" + v.(string)
+	}
+
+	items, err := listItems("authorities", filter, config)
+	if err != nil {
+		return err
+	}
+
+	matches := []map[string]interface{}{}
+	for _, item := range items {
+		if authorityMatchesFilters(item, d) {
+			matches = append(matches, item)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i]["id"].(float64) < matches[j]["id"].(float64)
+	})
+
+	ids := make([]string, 0, len(matches))
+	names := make([]string, 0, len(matches))
+	authorities := make([]map[string]interface{}, 0, len(matches))
+	for _, authority := range matches {
+		id := strconv.Itoa(int(authority["id"].(float64)))
+		ids = append(ids, id)
+		names = append(names, stringValue(authority, "name"))
+
+		certificateID, pem, err := authorityCertificatePEM(authority, config)
+		if err != nil {
+			return err
+		}
+		active, _ := authority["active"].(bool)
+
+		authorities = append(authorities, map[string]interface{}{
+			"id":                       id,
+			"name":                     stringValue(authority, "name"),
+			"owner":                    stringValue(authority, "owner"),
+			"plugin":                   authorityPlugin(authority),
+			"active":                   active,
+			"authority_certificate_id": certificateID,
+			"pem":                      pem,
+		})
+	}
+
+	d.SetId(strconv.Itoa(hashcode.String(filter + strings.Join(ids, ","))))
+	d.Set("ids", ids)
+	d.Set("names", names)
+
+	return d.Set("authorities", authorities)
+}
+
+// authorityMatchesFilters applies the name_regex, plugin, owner and active
+// filters shared by the data sources listing authorities.
+func authorityMatchesFilters(authority map[string]interface{}, d *schema.ResourceData) bool {
+	if v, ok := d.GetOk("name_regex"); ok && !regexp.MustCompile(v.(string)).MatchString(stringValue(authority, "name")) {
+		return false
+	}
+	if v, ok := d.GetOk("plugin"); ok && authorityPlugin(authority) != v.(string) {
+		return false
+	}
+	if v, ok := d.GetOk("owner"); ok && !strings.EqualFold(stringValue(authority, "owner"), v.(string)) {
+		return false
+	}
+	if v, ok := d.GetOk("active"); ok {
+		active, _ := strconv.ParseBool(v.(string))
+		if authorityActive, _ := authority["active"].(bool); authorityActive != active {
+			return false
+		}
+	}
+	return true
+}
+
+// authorityCertificatePEM returns the ID and PEM of an authority's
+// certificate as found in a listing. Older Lemur releases leave the body out
+// of listings, in which case the certificate is fetched.
+func authorityCertificatePEM(authority map[string]interface{}, config Config) (string, string, error) {
+	certificate, ok := authority["authorityCertificate"].(map[string]interface{})
+	if !ok {
+		return "", "", nil
+	}
+	id, ok := certificate["id"].(float64)
+	if !ok {
+		return "", "", nil
+	}
+
+	pem := stringValue(certificate, "body")
+	if pem == "" {
+		var err error
+		if _, pem, err = getPublicCertificateData(int(id), nil, config); err != nil {
+			return "", "", err
+		}
+	}
+
+	return strconv.Itoa(int(id)), pem, nil
+}
@@ -0,0 +1,94 @@
+package lemur
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDataSourceLemurAuthoritiesRead(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.addAuthority(1, "Root CA")
+	other := lemur.addAuthority(2, "Other CA")
+	other["owner"] = "Other@example.com"
+	other["plugin"] = map[string]interface{}{"slug": "acme-issuer"}
+	backup := lemur.addAuthority(3, "Root CA G2")
+	backup["owner"] = "TEAM@example.com"
+	backup["active"] = false
+	// Older Lemur releases name the plugin and leave the body out of listings.
+	backup["plugin"] = "cfssl-issuer"
+	backup["authorityCertificate"] = map[string]interface{}{"id": float64(103)}
+	// Lemur matches the owner filter by substring, so this one is listed too.
+	lemur.addAuthority(4, "Ops CA")["owner"] = "ops-team@example.com"
+
+	cases := []struct {
+		Config   map[string]interface{}
+		Filter   string
+		Expected []interface{}
+	}{
+		{
+			Config:   map[string]interface{}{},
+			Expected: []interface{}{"1", "2", "3", "4"},
+		},
+		{
+			Config:   map[string]interface{}{"name_regex": "^Root"},
+			Expected: []interface{}{"1", "3"},
+		},
+		{
+			Config:   map[string]interface{}{"plugin": "cfssl-issuer"},
+			Expected: []interface{}{"1", "3", "4"},
+		},
+		{
+			Config:   map[string]interface{}{"plugin": "acme-issuer"},
+			Expected: []interface{}{"2"},
+		},
+		{
+			Config:   map[string]interface{}{"owner": "team@example.com"},
+			Filter:   "owner;team@example.com",
+			Expected: []interface{}{"1", "3"},
+		},
+		{
+			Config:   map[string]interface{}{"active": "false"},
+			Expected: []interface{}{"3"},
+		},
+		{
+			Config:   map[string]interface{}{"name_regex": "CA$", "active": "true"},
+			Expected: []interface{}{"1", "2", "4"},
+		},
+	}
+
+	for i, tc := range cases {
+		lemur.lists = nil
+
+		d := dataSourceLemurAuthorities().TestResourceData()
+		for k, v := range tc.Config {
+			d.Set(k, v)
+		}
+		if err := dataSourceLemurAuthoritiesRead(d, lemur.config()); err != nil {
+			t.Fatalf("%d: err: %s", i, err)
+		}
+
+		if ids := d.Get("ids").([]interface{}); !reflect.DeepEqual(ids, tc.Expected) {
+			t.Errorf("%d: expected ids %v, got %v", i, tc.Expected, ids)
+		}
+		for _, query := range lemur.lists {
+			if filter := query.Get("filter"); filter != tc.Filter {
+				t.Errorf("%d: expected filter %q, got %q", i, tc.Filter, filter)
+			}
+		}
+
+		if i > 0 {
+			continue
+		}
+		if names := d.Get("names").([]interface{}); !reflect.DeepEqual(names, []interface{}{"Root CA", "Other CA", "Root CA G2", "Ops CA"}) {
+			t.Errorf("unexpected names: %v", names)
+		}
+		authority := d.Get("authorities").([]interface{})[2].(map[string]interface{})
+		if authority["plugin"] != "cfssl-issuer" || authority["authority_certificate_id"] != "103" {
+			t.Errorf("unexpected authority: %#v", authority)
+		}
+		if authority["pem"] != stringValue(lemur.certificates[103], "body") {
+			t.Errorf("expected the certificate of authority 3 to be fetched, got %q", authority["pem"])
+		}
+	}
+}
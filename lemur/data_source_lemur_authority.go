@@ -1,10 +1,7 @@
 package lemur
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strconv"
 
 	"github.com/hashicorp/terraform/helper/schema"
@@ -15,9 +12,56 @@ func dataSourceLemurAuthority() *schema.Resource {
 		Read: dataSourceLemurAuthorityRead,
 
 		Schema: map[string]*schema.Schema{
+			"id": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"name"},
+			},
 			"name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"id"},
+			},
+
+			"owner": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
+				Computed: true,
+			},
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"plugin": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"active": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"not_before": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"not_after": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"roles": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			// Empty for root authorities.
+			"parent_authority_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"authority_certificate_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
 			},
 
 			"pem": &schema.Schema{
@@ -25,6 +69,10 @@ func dataSourceLemurAuthority() *schema.Resource {
 				Optional: true,
 				Computed: true,
 			},
+			"chain": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"crt_base_64": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
@@ -37,18 +85,30 @@ func dataSourceLemurAuthority() *schema.Resource {
 func dataSourceLemurAuthorityRead(d *schema.ResourceData, meta interface{}) error {
 	config := meta.(Config)
 
-	if d.Id() != "" {
-		return fmt.Errorf("Error during making a request: %s", d.Id())
+	authority, err := findAuthority(d, config)
+	if err != nil {
+		return err
+	}
+
+	authorityID := int(authority["id"].(float64))
+	authorityCertificate, ok := authority["authorityCertificate"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Authority %d has no authority certificate", authorityID)
 	}
+	certificateID := int(authorityCertificate["id"].(float64))
 
-	authorityID, certificateID, err := findAuthority(d, config)
+	certificate, err := getCertificateByID(certificateID, config)
 	if err != nil {
 		return err
 	}
+	if certificate == nil {
+		return fmt.Errorf("Authority certificate %d of authority %d not found", certificateID, authorityID)
+	}
 
-	_, publicCert, err := getPublicCertificateData(certificateID, d, config)
+	publicCert := stringValue(certificate, "body")
+	cert, err := parsePEMCertificate(publicCert)
 	if err != nil {
-		return err
+		return fmt.Errorf("Error parsing certificate of authority %d: %s", authorityID, err)
 	}
 
 	crtBase64, err := exportCertificateCRT(certificateID, d, config)
@@ -56,70 +116,125 @@ func dataSourceLemurAuthorityRead(d *schema.ResourceData, meta interface{}) erro
 		return err
 	}
 
-	d.Set("pem", publicCert)
+	d.SetId(strconv.Itoa(authorityID))
+	d.Set("name", stringValue(authority, "name"))
+	d.Set("owner", stringValue(authority, "owner"))
+	d.Set("description", stringValue(authority, "description"))
+	d.Set("plugin", authorityPlugin(authority))
+	if active, ok := authority["active"].(bool); ok {
+		d.Set("active", active)
+	}
+	d.Set("not_before", formatCertificateTime(cert.NotBefore))
+	d.Set("not_after", formatCertificateTime(cert.NotAfter))
+	d.Set("roles", authorityRoles(authority))
+	d.Set("parent_authority_id", authorityParentID(authority, certificate))
+	d.Set("authority_certificate_id", strconv.Itoa(certificateID))
 
+	d.Set("pem", publicCert)
+	d.Set("chain", stringValue(certificate, "chain"))
 	d.Set("crt_base_64", crtBase64)
 
-	d.SetId(strconv.Itoa(authorityID))
-
 	return nil
 }
 
-func findAuthority(d *schema.ResourceData, config Config) (int, int, error) {
-	client := &http.Client{}
-	name := d.Get("name").(string)
+// findAuthority looks up the authority selected by either id or name.
+// Lookups by name prefer an active authority when several share the name.
+func findAuthority(d *schema.ResourceData, config Config) (map[string]interface{}, error) {
+	if v, ok := d.GetOk("id"); ok {
+		authorityID, err := strconv.Atoi(v.(string))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid authority ID: %s", v.(string))
+		}
 
-	findExistingURL := config.Host + "/api/1/authorities?filter=name;" + name
-	findExisting, err := http.NewRequest("GET", findExistingURL, nil)
-	if err != nil {
-		return -1, -1, fmt.Errorf("Error creating request: %s", findExistingURL)
+		authority, err := getAuthorityByID(authorityID, config)
+		if err != nil {
+			return nil, err
+		}
+		if authority == nil {
+			return nil, fmt.Errorf("No authority found with id %d", authorityID)
+		}
+		return authority, nil
 	}
-	findExisting.Header.Set("Authorization", "bearer "+config.Token)
 
-	findExistingResponse, err := client.Do(findExisting)
+	name, ok := d.GetOk("name")
+	if !ok {
+		return nil, fmt.Errorf("One of \"id\" or \"name\" must be set to look up an authority")
+	}
+
+	items, err := listItems("authorities", "name;"+name.(string), config)
 	if err != nil {
-		return -1, -1, fmt.Errorf("Error during making a request: %s", findExistingURL)
+		return nil, err
 	}
 
-	defer findExistingResponse.Body.Close()
+	var found map[string]interface{}
+	for _, item := range items {
+		if stringValue(item, "name") != name.(string) {
+			continue
+		}
+		if active, _ := item["active"].(bool); active {
+			return item, nil
+		}
+		if found == nil {
+			found = item
+		}
+	}
 
-	if findExistingResponse.StatusCode != 200 {
-		return -1, -1, fmt.Errorf("HTTP request error. Response code: %d", findExistingResponse.StatusCode)
+	if found == nil {
+		return nil, fmt.Errorf("Unable to find authority with name %s", name.(string))
 	}
 
-	responseBytes, err := ioutil.ReadAll(findExistingResponse.Body)
+	return found, nil
+}
+
+func getAuthorityByID(authorityID int, config Config) (map[string]interface{}, error) {
+	url := config.Host + "/api/1/authorities/" + strconv.Itoa(authorityID)
+
+	var authority map[string]interface{}
+	err := config.request("GET", url, nil, &authority)
+	if isNotFound(err) {
+		return nil, nil
+	}
 	if err != nil {
-		return -1, -1, fmt.Errorf("Error while reading response body. %s", err)
+		return nil, err
 	}
 
-	var authotitiesResponse map[string]interface{}
-	err = json.Unmarshal(responseBytes, &authotitiesResponse)
-	if err != nil {
-		return -1, -1, fmt.Errorf("Error while reading response body. %s", err)
-	}
-
-	authorityID := 0
-	certificateID := 0
-	totalResults := authotitiesResponse["total"].(float64)
-	if totalResults > 0 {
-		items := authotitiesResponse["items"].([]interface{})
-		for _, item := range items {
-			itemMap := item.(map[string]interface{})
-			if itemMap["active"].(bool) && itemMap["name"].(string) == name {
-				authorityID = int(itemMap["id"].(float64))
-				certificateData := itemMap["authorityCertificate"].(map[string]interface{})
-
-				certificateID = int(certificateData["id"].(float64))
-				break
+	return authority, nil
+}
+
+func authorityPlugin(authority map[string]interface{}) string {
+	switch plugin := authority["plugin"].(type) {
+	case string:
+		return plugin
+	case map[string]interface{}:
+		return stringValue(plugin, "slug")
+	}
+	return ""
+}
+
+func authorityRoles(authority map[string]interface{}) []string {
+	roles := []string{}
+	if v, ok := authority["roles"].([]interface{}); ok {
+		for _, role := range v {
+			if role, ok := role.(map[string]interface{}); ok {
+				roles = append(roles, stringValue(role, "name"))
 			}
 		}
-	} else {
-		return -1, -1, fmt.Errorf("Unable to find authotity with name. %s", name)
 	}
+	return roles
+}
+
+// authorityParentID returns the ID of the authority that issued an
+// authority's certificate, or "" for a root authority.
+func authorityParentID(authority map[string]interface{}, certificate map[string]interface{}) string {
+	authorityID, _ := authority["id"].(float64)
 
-	if authorityID == 0 {
-		return -1, -1, fmt.Errorf("Unable to find authotity with name. %s", name)
+	for _, parent := range []interface{}{authority["parent"], certificate["authority"]} {
+		if parent, ok := parent.(map[string]interface{}); ok {
+			if id, ok := parent["id"].(float64); ok && id != authorityID {
+				return strconv.Itoa(int(id))
+			}
+		}
 	}
 
-	return authorityID, certificateID, nil
+	return ""
 }
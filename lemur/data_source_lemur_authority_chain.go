@@ -0,0 +1,142 @@
+package lemur
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceLemurAuthorityChain() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceLemurAuthorityChainRead,
+
+		Schema: map[string]*schema.Schema{
+			"id": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"name"},
+			},
+			"name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"id"},
+			},
+			"include_root": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			// Ordered from the starting authority towards the root.
+			"pems": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"bundle": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			// The Lemur authorities along the chain. Issuers only found in
+			// a stored chain have no entry.
+			"authority_ids": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceLemurAuthorityChainRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(Config)
+
+	authority, err := findAuthority(d, config)
+	if err != nil {
+		return err
+	}
+
+	link, parentID, chain, err := authorityChainLink(authority, config)
+	if err != nil {
+		return err
+	}
+
+	links := []chainLink{link}
+	chains := []string{chain}
+	seen := map[string]bool{string(link.Cert.Raw): true}
+	var candidates []chainLink
+
+	for !isSelfSigned(links[len(links)-1].Cert) {
+		if len(links) >= maxChainLength {
+			return fmt.Errorf("Authority chain of %s is longer than %d certificates", stringValue(authority, "name"), maxChainLength)
+		}
+		current := links[len(links)-1]
+
+		var issuer *chainLink
+		if parentID != "" {
+			id, _ := strconv.Atoi(parentID)
+			parent, err := getAuthorityByID(id, config)
+			if err != nil {
+				return err
+			}
+			parentID = ""
+			if parent != nil {
+				link, parentID, chain, err = authorityChainLink(parent, config)
+				if err != nil {
+					return err
+				}
+				issuer = &link
+				chains = append(chains, chain)
+			}
+		}
+
+		if issuer == nil {
+			if candidates == nil {
+				candidates, err = authorityChainCandidates(chains, config)
+				if err != nil {
+					return err
+				}
+			}
+			issuer = findIssuer(current.Cert, candidates)
+		}
+		if issuer == nil {
+			return fmt.Errorf("Unable to find the issuer of %q", current.Cert.Subject.CommonName)
+		}
+
+		if seen[string(issuer.Cert.Raw)] {
+			return fmt.Errorf("Authority chain of %s contains a loop at %q", stringValue(authority, "name"), issuer.Cert.Subject.CommonName)
+		}
+		seen[string(issuer.Cert.Raw)] = true
+		links = append(links, *issuer)
+	}
+
+	if err := verifyChain(links); err != nil {
+		return err
+	}
+
+	// The starting authority is always returned, even when it is the root.
+	if !d.Get("include_root").(bool) && len(links) > 1 {
+		links = links[:len(links)-1]
+	}
+
+	pems := make([]string, 0, len(links))
+	authorityIDs := []string{}
+	for _, link := range links {
+		pems = append(pems, encodePEMCertificates([]*x509.Certificate{link.Cert}))
+		if link.AuthorityID != "" {
+			authorityIDs = append(authorityIDs, link.AuthorityID)
+		}
+	}
+
+	d.SetId(strconv.Itoa(int(authority["id"].(float64))))
+	d.Set("name", stringValue(authority, "name"))
+	d.Set("pems", pems)
+	d.Set("authority_ids", authorityIDs)
+
+	return d.Set("bundle", strings.Join(pems, ""))
+}
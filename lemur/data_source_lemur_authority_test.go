@@ -0,0 +1,72 @@
+package lemur
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDataSourceLemurAuthorityRead_id(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.addAuthority(1, "Root CA")
+	lemur.addAuthority(2, "Other CA")
+
+	d := dataSourceLemurAuthority().TestResourceData()
+	d.Set("id", "2")
+	if err := dataSourceLemurAuthorityRead(d, lemur.config()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if d.Id() != "2" || d.Get("name").(string) != "Other CA" || d.Get("authority_certificate_id").(string) != "102" {
+		t.Fatalf("unexpected authority %s: %s", d.Id(), d.Get("name"))
+	}
+	if d.Get("plugin").(string) != "cfssl-issuer" || d.Get("parent_authority_id").(string) != "" {
+		t.Fatalf("unexpected plugin %q or parent %q", d.Get("plugin"), d.Get("parent_authority_id"))
+	}
+	if d.Get("pem").(string) != stringValue(lemur.certificates[102], "body") || d.Get("crt_base_64").(string) == "" {
+		t.Fatal("expected the authority certificate in state")
+	}
+
+	d = dataSourceLemurAuthority().TestResourceData()
+	d.Set("id", "9")
+	err := dataSourceLemurAuthorityRead(d, lemur.config())
+	if err == nil || !strings.Contains(err.Error(), "No authority found with id 9") {
+		t.Fatalf("expected an error for a missing authority, got: %v", err)
+	}
+}
+
+func TestDataSourceLemurAuthorityRead_name(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.addAuthority(1, "Root CA")["active"] = false
+	// Lemur matches names by substring, so this one is listed too.
+	lemur.addAuthority(2, "Root CA G2")
+	lemur.addAuthority(3, "Root CA")
+
+	read := func(name string) (string, error) {
+		lemur.lists = nil
+		d := dataSourceLemurAuthority().TestResourceData()
+		d.Set("name", name)
+		err := dataSourceLemurAuthorityRead(d, lemur.config())
+		if len(lemur.lists) == 0 || lemur.lists[0].Get("filter") != "name;"+name {
+			t.Fatalf("expected a listing filtered by name, got %v", lemur.lists)
+		}
+		return d.Id(), err
+	}
+
+	if id, err := read("Root CA"); err != nil || id != "3" {
+		t.Fatalf("expected the active authority 3, got %q (err: %v)", id, err)
+	}
+
+	lemur.authorities[3]["active"] = false
+	if id, err := read("Root CA"); err != nil || id != "1" {
+		t.Fatalf("expected the first authority without an active one, got %q (err: %v)", id, err)
+	}
+
+	for _, name := range []string{"Root", "Missing CA"} {
+		_, err := read(name)
+		if err == nil || !strings.Contains(err.Error(), "Unable to find authority with name "+name) {
+			t.Fatalf("expected an error for %q, got: %v", name, err)
+		}
+	}
+}
@@ -1,72 +1,152 @@
 package lemur
 
 import (
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform/helper/schema"
 )
 
+// certificateLookupKeys lists the arguments a certificate can be looked up by.
+var certificateLookupKeys = []string{"id", "name", "serial", "common_name", "sha256_fingerprint"}
+
 func dataSourceLemurCertificate() *schema.Resource {
 	return &schema.Resource{
 		Read: dataSourceLemurCertificateRead,
 
 		Schema: map[string]*schema.Schema{
+			"id": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"name", "serial", "common_name", "sha256_fingerprint"},
+			},
 			"name": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"id", "serial", "common_name", "sha256_fingerprint"},
+			},
+			"serial": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"id", "name", "common_name", "sha256_fingerprint"},
 			},
 			"common_name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"id", "name", "serial", "sha256_fingerprint"},
+			},
+			"sha256_fingerprint": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"id", "name", "serial", "common_name"},
+			},
+			"most_recent": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"private_key_handling": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "state",
+				ValidateFunc: validateStringInSlice(privateKeyHandlingModes),
+			},
+			"strict_verification": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"export_formats": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateStringInSlice(certificateExportFormatNames()),
+				},
+				Set: schema.HashString,
+			},
+			"pkcs12_passphrase": &schema.Schema{
+				Type:      schema.TypeString,
+				Optional:  true,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"jks_keystore_passphrase": &schema.Schema{
+				Type:      schema.TypeString,
+				Optional:  true,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"jks_truststore_passphrase": &schema.Schema{
+				Type:      schema.TypeString,
+				Optional:  true,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"jks_alias": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
+			},
+			"pkcs8_passphrase": &schema.Schema{
+				Type:      schema.TypeString,
+				Optional:  true,
+				Computed:  true,
+				Sensitive: true,
+			},
+
+			"certificate_id": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
 			},
 			"owner": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
+				Computed: true,
 			},
 			"authority": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
+				Computed: true,
 			},
 			"description": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
+				Computed: true,
 			},
-			"validity_years": &schema.Schema{
-				Type:     schema.TypeInt,
-				Required: true,
+			"active": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
 			},
-			"organization": &schema.Schema{
-				Type:     schema.TypeInt,
-				Optional: true,
-			},
-			"location": &schema.Schema{
-				Type:     schema.TypeInt,
-				Optional: true,
+			"rotation": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
 			},
-			"state": &schema.Schema{
-				Type:     schema.TypeInt,
-				Optional: true,
-			},
-			"organizational_unit": &schema.Schema{
-				Type:     schema.TypeInt,
-				Optional: true,
+			"rotation_policy": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
 			},
-			"country": &schema.Schema{
-				Type:     schema.TypeInt,
-				Optional: true,
+			"dns_provider": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
 			},
 			"san": {
 				Type:     schema.TypeSet,
-				Optional: true,
+				Computed: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"type": {
 							Type:     schema.TypeString,
-							Required: true,
+							Computed: true,
 						},
 						"value": {
 							Type:     schema.TypeString,
-							Required: true,
+							Computed: true,
 						},
 					},
 				},
@@ -74,38 +154,134 @@ func dataSourceLemurCertificate() *schema.Resource {
 			},
 			"extended_key_usage": {
 				Type:     schema.TypeSet,
-				Optional: true,
-				MaxItems: 1,
+				Computed: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"use_client_authentication": {
 							Type:     schema.TypeBool,
-							Required: true,
+							Computed: true,
 						},
 						"use_server_authentication": {
 							Type:     schema.TypeBool,
-							Required: true,
+							Computed: true,
+						},
+						"use_code_signing": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"use_email_protection": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"use_time_stamping": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"use_ocsp_signing": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"oids": {
+							Type:     schema.TypeSet,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+							Set:      schema.HashString,
 						},
 					},
 				},
-				Set: resourceSANHash,
+				Set: resourceEKUHash,
+			},
+
+			"not_before": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"not_after": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"issuer": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"bits": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"key_type": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"san_names": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"sha1_fingerprint": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"lemur_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"chain_valid": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"key_matches": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
 			},
 
 			"pem_chain": &schema.Schema{
 				Type:      schema.TypeString,
-				Optional:  true,
 				Computed:  true,
 				Sensitive: true,
 			},
 			"pem_public_certificate": &schema.Schema{
 				Type:      schema.TypeString,
-				Optional:  true,
 				Computed:  true,
 				Sensitive: true,
 			},
 			"pem_private_certificate": &schema.Schema{
 				Type:      schema.TypeString,
-				Optional:  true,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"pkcs_base_64": &schema.Schema{
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"jks_keystore_base_64": &schema.Schema{
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"jks_truststore_base_64": &schema.Schema{
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"pkcs7_base_64": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"der_base_64": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"pem_full_chain": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"pkcs8_encrypted_private_key": &schema.Schema{
+				Type:      schema.TypeString,
 				Computed:  true,
 				Sensitive: true,
 			},
@@ -116,23 +292,16 @@ func dataSourceLemurCertificate() *schema.Resource {
 func dataSourceLemurCertificateRead(d *schema.ResourceData, meta interface{}) error {
 	config := meta.(Config)
 
-	certificate, err := getCertificate(d, config)
-	if err != nil {
+	if err := validatePrivateKeyHandling(d); err != nil {
 		return err
 	}
-	if certificate == nil {
-		d.SetId("")
-		return nil
-	}
-
-	authority := certificate["authority"].(map[string]interface{})
 
-	d.Set("authority", authority["name"].(string))
-	d.Set("common_name", certificate["commonName"].(string))
-	d.Set("owner", certificate["owner"].(string))
+	certificate, err := lookupCertificate(d, config)
+	if err != nil {
+		return err
+	}
 
 	certificateID := int(certificate["id"].(float64))
-	d.Set("certificate_id", certificateID)
 	d.SetId(strconv.Itoa(certificateID))
 
 	chain, publicCert, err := getPublicCertificateData(certificateID, d, config)
@@ -140,14 +309,173 @@ func dataSourceLemurCertificateRead(d *schema.ResourceData, meta interface{}) er
 		return err
 	}
 
-	privateCert, err := getPrivateCertificateData(certificateID, d, config)
-	if err != nil {
-		return err
+	privateCert := ""
+	if privateKeyInState(d) {
+		privateCert, err = getPrivateCertificateData(certificateID, d, config)
+		if err != nil {
+			return err
+		}
 	}
 
 	d.Set("pem_chain", chain)
 	d.Set("pem_public_certificate", publicCert)
 	d.Set("pem_private_certificate", privateCert)
 
-	return nil
+	if err := setCertificateData(d, certificate, publicCert); err != nil {
+		return err
+	}
+
+	if err := setCertificateVerification(d); err != nil {
+		return err
+	}
+
+	formats := d.Get("export_formats").(*schema.Set)
+	return exportCertificateFormats(certificateID, formats, d, config)
+}
+
+// setCertificateData sets the attributes a looked up certificate shares with
+// the lemur_certificate resource.
+func setCertificateData(d *schema.ResourceData, certificate map[string]interface{}, publicCert string) error {
+	d.Set("certificate_id", int(certificate["id"].(float64)))
+	d.Set("common_name", stringValue(certificate, "commonName"))
+	d.Set("owner", stringValue(certificate, "owner"))
+	d.Set("description", stringValue(certificate, "description"))
+
+	if authority, ok := certificate["authority"].(map[string]interface{}); ok {
+		d.Set("authority", stringValue(authority, "name"))
+	}
+	if active, ok := certificate["active"].(bool); ok {
+		d.Set("active", active)
+	}
+	if rotation, ok := certificate["rotation"].(bool); ok {
+		d.Set("rotation", rotation)
+	}
+	if rotationPolicy, ok := certificate["rotationPolicy"].(map[string]interface{}); ok {
+		d.Set("rotation_policy", stringValue(rotationPolicy, "name"))
+	}
+	if dnsProvider, ok := certificate["dnsProvider"].(map[string]interface{}); ok {
+		if id, ok := dnsProvider["id"].(float64); ok {
+			d.Set("dns_provider", strconv.Itoa(int(id)))
+		}
+	}
+
+	if err := setCertificateMetadata(d, certificate, publicCert); err != nil {
+		return err
+	}
+
+	cert, err := parsePEMCertificate(publicCert)
+	if err != nil {
+		return fmt.Errorf("Error parsing certificate %s: %s", d.Id(), err)
+	}
+
+	noSANs := schema.NewSet(resourceSANHash, nil)
+	if err := d.Set("san", flattenSubjectAltNames(cert, "", noSANs)); err != nil {
+		return err
+	}
+
+	return d.Set("extended_key_usage", flattenExtendedKeyUsage(cert, schema.NewSet(resourceEKUHash, nil)))
+}
+
+// lookupCertificate finds the single certificate matching whichever lookup
+// argument is set.
+func lookupCertificate(d *schema.ResourceData, config Config) (map[string]interface{}, error) {
+	if v, ok := d.GetOk("id"); ok {
+		certificateID, err := strconv.Atoi(v.(string))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid certificate ID: %s", v.(string))
+		}
+
+		certificate, err := getCertificateByID(certificateID, config)
+		if err != nil {
+			return nil, err
+		}
+		if certificate == nil {
+			return nil, fmt.Errorf("No certificate found with id %d", certificateID)
+		}
+		return certificate, nil
+	}
+
+	var key, value, filter string
+	var match func(map[string]interface{}) bool
+
+	if v, ok := d.GetOk("name"); ok {
+		key, value = "name", v.(string)
+		filter = "name;" + value
+		match = func(certificate map[string]interface{}) bool {
+			return stringValue(certificate, "name") == value
+		}
+	} else if v, ok := d.GetOk("serial"); ok {
+		key, value = "serial", v.(string)
+		filter = "serial;" + value
+		match = func(certificate map[string]interface{}) bool {
+			return stringValue(certificate, "serial") == value
+		}
+	} else if v, ok := d.GetOk("common_name"); ok {
+		key, value = "common_name", v.(string)
+		filter = "cn;" + value
+		match = func(certificate map[string]interface{}) bool {
+			return strings.EqualFold(stringValue(certificate, "commonName"), value) ||
+				strings.EqualFold(stringValue(certificate, "cn"), value)
+		}
+	} else if v, ok := d.GetOk("sha256_fingerprint"); ok {
+		// Lemur cannot filter by fingerprint, so every certificate has to be
+		// fetched and hashed locally.
+		key, value = "sha256_fingerprint", normalizeFingerprint(v.(string))
+		match = func(certificate map[string]interface{}) bool {
+			cert, err := parsePEMCertificate(stringValue(certificate, "body"))
+			if err != nil {
+				return false
+			}
+			_, sha256Fingerprint := certificateFingerprints(cert)
+			return sha256Fingerprint == value
+		}
+	} else {
+		return nil, fmt.Errorf("One of %q must be set to look up a certificate", certificateLookupKeys)
+	}
+
+	items, err := listItems("certificates", filter, config)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []map[string]interface{}{}
+	for _, item := range items {
+		if match(item) {
+			matches = append(matches, item)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("No certificate found with %s %s", key, value)
+	}
+	if len(matches) > 1 {
+		if d.Get("most_recent").(bool) {
+			sortCertificatesByNotBefore(matches)
+			return matches[0], nil
+		}
+		return nil, fmt.Errorf("%d certificates found with %s %s, use a more specific lookup or set most_recent", len(matches), key, value)
+	}
+
+	return matches[0], nil
+}
+
+// sortCertificatesByNotBefore orders certificates newest first, falling back
+// to the ID when the validity start is the same.
+func sortCertificatesByNotBefore(certificates []map[string]interface{}) {
+	notBefore := func(certificate map[string]interface{}) time.Time {
+		t, _ := time.Parse(time.RFC3339, stringValue(certificate, "notBefore"))
+		return t
+	}
+
+	sort.SliceStable(certificates, func(i, j int) bool {
+		a, b := notBefore(certificates[i]), notBefore(certificates[j])
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return certificates[i]["id"].(float64) > certificates[j]["id"].(float64)
+	})
+}
+
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.Replace(fingerprint, ":", "", -1))
 }
@@ -0,0 +1,237 @@
+package lemur
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceLemurCertificates() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceLemurCertificatesRead,
+
+		Schema: map[string]*schema.Schema{
+			"owner": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"authority": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			// Matches the common name or any of the certificate's domains.
+			"domain": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			// A string so that leaving it unset lists both active and
+			// inactive certificates.
+			"active": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateBool,
+			},
+			"expiring_within_days": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validateIntAtLeast(0),
+			},
+
+			"ids": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"certificates": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"common_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"owner": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"authority": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"serial": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"not_before": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"not_after": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"status": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"active": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"domains": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceLemurCertificatesRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(Config)
+
+	// Lemur accepts a single filter, so the most selective one is sent and
+	// every filter is applied to the results.
+	filter := ""
+	if v, ok := d.GetOk("owner"); ok {
+		filter = "owner;" + v.(string)
+	} else if v, ok := d.GetOk("domain"); ok {
+		filter = "name;" + v.(string)
+	}
+
+	items, err := listItems("certificates", filter, config)
+	if err != nil {
+		return err
+	}
+
+	matches := []map[string]interface{}{}
+	for _, item := range items {
+		ok, err := certificateMatchesFilters(item, d)
+		if err != nil {
+			return err
+		}
+		if ok {
+			matches = append(matches, item)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i]["id"].(float64) < matches[j]["id"].(float64)
+	})
+
+	ids := make([]string, 0, len(matches))
+	certificates := make([]map[string]interface{}, 0, len(matches))
+	for _, certificate := range matches {
+		id := strconv.Itoa(int(certificate["id"].(float64)))
+		ids = append(ids, id)
+
+		authority := ""
+		if v, ok := certificate["authority"].(map[string]interface{}); ok {
+			authority = stringValue(v, "name")
+		}
+		active, _ := certificate["active"].(bool)
+
+		certificates = append(certificates, map[string]interface{}{
+			"id":          id,
+			"name":        stringValue(certificate, "name"),
+			"common_name": stringValue(certificate, "commonName"),
+			"owner":       stringValue(certificate, "owner"),
+			"authority":   authority,
+			"serial":      stringValue(certificate, "serial"),
+			"not_before":  stringValue(certificate, "notBefore"),
+			"not_after":   stringValue(certificate, "notAfter"),
+			"status":      stringValue(certificate, "status"),
+			"active":      active,
+			"domains":     certificateDomains(certificate),
+		})
+	}
+
+	d.SetId(strconv.Itoa(hashcode.String(filter + strings.Join(ids, ","))))
+	d.Set("ids", ids)
+
+	return d.Set("certificates", certificates)
+}
+
+func certificateDomains(certificate map[string]interface{}) []string {
+	domains := []string{}
+	if v, ok := certificate["domains"].([]interface{}); ok {
+		for _, domain := range v {
+			if domain, ok := domain.(map[string]interface{}); ok {
+				domains = append(domains, stringValue(domain, "name"))
+			}
+		}
+	}
+	return domains
+}
+
+// certificateMatchesFilters applies the filters of the lemur_certificates
+// data source to a single certificate.
+func certificateMatchesFilters(certificate map[string]interface{}, d *schema.ResourceData) (bool, error) {
+	if v, ok := d.GetOk("owner"); ok && !strings.EqualFold(stringValue(certificate, "owner"), v.(string)) {
+		return false, nil
+	}
+
+	if v, ok := d.GetOk("authority"); ok {
+		authority, _ := certificate["authority"].(map[string]interface{})
+		if authority == nil || stringValue(authority, "name") != v.(string) {
+			return false, nil
+		}
+	}
+
+	if v, ok := d.GetOk("domain"); ok {
+		found := strings.EqualFold(stringValue(certificate, "commonName"), v.(string))
+		for _, domain := range certificateDomains(certificate) {
+			if strings.EqualFold(domain, v.(string)) {
+				found = true
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	if v, ok := d.GetOk("status"); ok && stringValue(certificate, "status") != v.(string) {
+		return false, nil
+	}
+
+	if v, ok := d.GetOk("active"); ok {
+		active, _ := strconv.ParseBool(v.(string))
+		if certificateActive, _ := certificate["active"].(bool); certificateActive != active {
+			return false, nil
+		}
+	}
+
+	if v, ok := d.GetOk("expiring_within_days"); ok {
+		notAfter, err := time.Parse(time.RFC3339, stringValue(certificate, "notAfter"))
+		if err != nil {
+			return false, fmt.Errorf("Error parsing notAfter of certificate %v: %s", certificate["id"], err)
+		}
+		now := time.Now()
+		if notAfter.Before(now) || notAfter.After(now.AddDate(0, 0, v.(int))) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,137 @@
+package lemur
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func testListedCertificate(id int, owner string, commonName string, authority string, status string, active bool, notAfter time.Time, domains ...string) map[string]interface{} {
+	domainList := []interface{}{}
+	for _, domain := range domains {
+		domainList = append(domainList, map[string]interface{}{"name": domain})
+	}
+	return map[string]interface{}{
+		"id":         float64(id),
+		"name":       commonName,
+		"commonName": commonName,
+		"owner":      owner,
+		"authority":  map[string]interface{}{"name": authority},
+		"status":     status,
+		"active":     active,
+		"notAfter":   notAfter.Format(time.RFC3339),
+		"domains":    domainList,
+	}
+}
+
+func TestDataSourceLemurCertificatesRead(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+
+	now := time.Now().UTC()
+	later := now.AddDate(10, 0, 0)
+
+	// More certificates than fit on a single page.
+	for id := 1; id <= 150; id++ {
+		lemur.certificates[id] = testListedCertificate(id, "bulk@example.com", fmt.Sprintf("bulk-%d.example.org", id), "Bulk CA", "valid", true, later)
+	}
+	lemur.certificates[201] = testListedCertificate(201, "team@example.com", "www.example.com", "Internal CA", "valid", true, now.AddDate(0, 0, 10), "www.example.com", "api.example.com")
+	lemur.certificates[202] = testListedCertificate(202, "Team@Example.com", "example.com", "Public CA", "revoked", false, now.AddDate(0, 0, 60), "example.com", "myapi.example.com")
+	lemur.certificates[203] = testListedCertificate(203, "other@example.com", "api.example.com", "Internal CA", "expired", true, now.AddDate(0, 0, -1), "api.example.com")
+
+	all := []interface{}{}
+	for id := 1; id <= 150; id++ {
+		all = append(all, strconv.Itoa(id))
+	}
+	all = append(all, "201", "202", "203")
+
+	cases := []struct {
+		Config   map[string]interface{}
+		Filter   string
+		Expected []interface{}
+	}{
+		{
+			Config:   map[string]interface{}{},
+			Expected: all,
+		},
+		{
+			Config:   map[string]interface{}{"owner": "team@example.com"},
+			Filter:   "owner;team@example.com",
+			Expected: []interface{}{"201", "202"},
+		},
+		{
+			Config:   map[string]interface{}{"authority": "Internal CA"},
+			Expected: []interface{}{"201", "203"},
+		},
+		{
+			// Lemur also returns myapi.example.com, which is left out.
+			Config:   map[string]interface{}{"domain": "api.example.com"},
+			Filter:   "name;api.example.com",
+			Expected: []interface{}{"201", "203"},
+		},
+		{
+			Config:   map[string]interface{}{"status": "revoked"},
+			Expected: []interface{}{"202"},
+		},
+		{
+			Config:   map[string]interface{}{"active": "false"},
+			Expected: []interface{}{"202"},
+		},
+		{
+			Config:   map[string]interface{}{"expiring_within_days": 30},
+			Expected: []interface{}{"201"},
+		},
+		{
+			Config:   map[string]interface{}{"owner": "team@example.com", "domain": "example.com", "active": "true"},
+			Filter:   "owner;team@example.com",
+			Expected: []interface{}{},
+		},
+		{
+			Config:   map[string]interface{}{"owner": "team@example.com", "active": "true"},
+			Filter:   "owner;team@example.com",
+			Expected: []interface{}{"201"},
+		},
+	}
+
+	for i, tc := range cases {
+		lemur.lists = nil
+
+		d := dataSourceLemurCertificates().TestResourceData()
+		for k, v := range tc.Config {
+			d.Set(k, v)
+		}
+		if err := dataSourceLemurCertificatesRead(d, lemur.config()); err != nil {
+			t.Fatalf("%d: err: %s", i, err)
+		}
+
+		if ids := d.Get("ids").([]interface{}); !reflect.DeepEqual(ids, tc.Expected) {
+			t.Errorf("%d: expected ids %v, got %v", i, tc.Expected, ids)
+		}
+		for _, query := range lemur.lists {
+			if filter := query.Get("filter"); filter != tc.Filter {
+				t.Errorf("%d: expected filter %q, got %q", i, tc.Filter, filter)
+			}
+		}
+	}
+
+	// Without a filter Lemur serves every certificate over two pages.
+	lemur.lists = nil
+	d := dataSourceLemurCertificates().TestResourceData()
+	if err := dataSourceLemurCertificatesRead(d, lemur.config()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(lemur.lists) != 2 || lemur.lists[0].Get("page") != "1" || lemur.lists[1].Get("page") != "2" {
+		t.Fatalf("expected two pages to be requested, got %v", lemur.lists)
+	}
+
+	certificates := d.Get("certificates").([]interface{})
+	certificate := certificates[len(certificates)-2].(map[string]interface{})
+	if certificate["owner"] != "Team@Example.com" || certificate["authority"] != "Public CA" || certificate["active"] != false {
+		t.Fatalf("unexpected certificate: %#v", certificate)
+	}
+	if domains := certificate["domains"].([]interface{}); !reflect.DeepEqual(domains, []interface{}{"example.com", "myapi.example.com"}) {
+		t.Fatalf("unexpected domains: %v", domains)
+	}
+}
@@ -0,0 +1,161 @@
+package lemur
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceLemurTrustBundle() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceLemurTrustBundleRead,
+
+		Schema: map[string]*schema.Schema{
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRegexp,
+			},
+			"plugin": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"owner": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"active": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "true",
+				ValidateFunc: validateBool,
+			},
+			// The truststores are rebuilt on every read, so the passphrase has
+			// to be stable for the output to be. Defaults to the one Java uses
+			// for its own cacerts.
+			"passphrase": &schema.Schema{
+				Type:      schema.TypeString,
+				Optional:  true,
+				Default:   "changeit",
+				Sensitive: true,
+			},
+
+			"authority_ids": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"sha256_fingerprints": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"pem_bundle": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"jks_truststore_base_64": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"pkcs12_truststore_base_64": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceLemurTrustBundleRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(Config)
+
+	filter := ""
+	if v, ok := d.GetOk("owner"); ok {
+		filter = "owner;" + v.(string)
+	}
+
+	items, err := listItems("authorities", filter, config)
+	if err != nil {
+		return err
+	}
+
+	authorityIDs := []string{}
+	certs := map[string]*x509.Certificate{}
+	for _, item := range items {
+		if !authorityMatchesFilters(item, d) {
+			continue
+		}
+
+		name := stringValue(item, "name")
+		id, ok := item["id"].(float64)
+		if !ok {
+			return fmt.Errorf("Authority %s has no ID", name)
+		}
+
+		_, pem, err := authorityCertificatePEM(item, config)
+		if err != nil {
+			return err
+		}
+		if pem == "" {
+			log.Printf("[WARN] Authority %s has no certificate, leaving it out of the trust bundle", name)
+			continue
+		}
+		cert, err := parsePEMCertificate(pem)
+		if err != nil {
+			return fmt.Errorf("Error parsing certificate of authority %s: %s", name, err)
+		}
+
+		// Several authorities can share a certificate, e.g. when a CA was
+		// imported twice, but it only belongs in the bundle once.
+		_, sha256Fingerprint := certificateFingerprints(cert)
+		certs[sha256Fingerprint] = cert
+		authorityIDs = append(authorityIDs, strconv.Itoa(int(id)))
+	}
+
+	fingerprints := make([]string, 0, len(certs))
+	for fingerprint := range certs {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Slice(fingerprints, func(i, j int) bool {
+		a := strings.ToLower(certs[fingerprints[i]].Subject.CommonName)
+		b := strings.ToLower(certs[fingerprints[j]].Subject.CommonName)
+		if a != b {
+			return a < b
+		}
+		return fingerprints[i] < fingerprints[j]
+	})
+
+	sorted := make([]*x509.Certificate, 0, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		sorted = append(sorted, certs[fingerprint])
+	}
+	sort.Slice(authorityIDs, func(i, j int) bool {
+		a, _ := strconv.Atoi(authorityIDs[i])
+		b, _ := strconv.Atoi(authorityIDs[j])
+		return a < b
+	})
+
+	aliases := truststoreAliases(sorted)
+	passphrase := d.Get("passphrase").(string)
+
+	pkcs12, err := encodePKCS12Truststore(sorted, aliases, passphrase)
+	if err != nil {
+		return fmt.Errorf("Error building PKCS#12 truststore: %s", err)
+	}
+
+	d.SetId(strconv.Itoa(hashcode.String(strings.Join(fingerprints, ","))))
+	d.Set("authority_ids", authorityIDs)
+	d.Set("sha256_fingerprints", fingerprints)
+	d.Set("pem_bundle", encodePEMCertificates(sorted))
+	d.Set("jks_truststore_base_64", base64.StdEncoding.EncodeToString(encodeJKSTruststore(sorted, aliases, passphrase)))
+	d.Set("pkcs12_truststore_base_64", base64.StdEncoding.EncodeToString(pkcs12))
+
+	return nil
+}
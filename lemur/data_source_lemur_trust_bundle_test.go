@@ -0,0 +1,39 @@
+package lemur
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDataSourceLemurTrustBundleRead(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.addAuthority(1, "Root CA")
+	lemur.addAuthority(2, "Other CA")
+	// An authority still being set up has no certificate yet.
+	delete(lemur.addAuthority(3, "Pending CA"), "authorityCertificate")
+
+	d := dataSourceLemurTrustBundle().TestResourceData()
+	d.Set("passphrase", "changeit")
+	if err := dataSourceLemurTrustBundleRead(d, lemur.config()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if v := d.Get("authority_ids").([]interface{}); !reflect.DeepEqual(v, []interface{}{"1", "2"}) {
+		t.Fatalf("unexpected authority_ids: %v", v)
+	}
+	bundle := d.Get("pem_bundle").(string)
+	if strings.Count(bundle, "BEGIN CERTIFICATE") != 2 {
+		t.Fatalf("expected two certificates in the bundle:\n%s", bundle)
+	}
+	if !strings.HasPrefix(bundle, stringValue(lemur.certificates[102], "body")) {
+		t.Fatalf("expected the bundle to be sorted by common name:\n%s", bundle)
+	}
+
+	delete(lemur.authorities[2], "id")
+	err := dataSourceLemurTrustBundleRead(d, lemur.config())
+	if err == nil || !strings.Contains(err.Error(), "Other CA") {
+		t.Fatalf("expected an error for the authority without an ID, got: %v", err)
+	}
+}
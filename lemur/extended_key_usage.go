@@ -0,0 +1,112 @@
+package lemur
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+var oidExtensionExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+
+// extendedKeyUsages maps the extended_key_usage flags to their usages.
+var extendedKeyUsages = []struct {
+	Attribute string
+	Usage     x509.ExtKeyUsage
+	OID       asn1.ObjectIdentifier
+}{
+	{"use_server_authentication", x509.ExtKeyUsageServerAuth, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1}},
+	{"use_client_authentication", x509.ExtKeyUsageClientAuth, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 2}},
+	{"use_code_signing", x509.ExtKeyUsageCodeSigning, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 3}},
+	{"use_email_protection", x509.ExtKeyUsageEmailProtection, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 4}},
+	{"use_time_stamping", x509.ExtKeyUsageTimeStamping, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 8}},
+	{"use_ocsp_signing", x509.ExtKeyUsageOCSPSigning, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 9}},
+}
+
+func parseOID(value string) (asn1.ObjectIdentifier, error) {
+	if _, errs := validateOID(value, "oid"); len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	var oid asn1.ObjectIdentifier
+	for _, part := range strings.Split(value, ".") {
+		n, _ := strconv.Atoi(part)
+		oid = append(oid, n)
+	}
+	return oid, nil
+}
+
+// encodeExtendedKeyUsage builds the base64 encoded DER value of an extended
+// key usage extension holding both the flagged usages and the extra OIDs.
+func encodeExtendedKeyUsage(keyUsage map[string]interface{}) (string, error) {
+	oids := []asn1.ObjectIdentifier{}
+	for _, usage := range extendedKeyUsages {
+		if keyUsage[usage.Attribute].(bool) {
+			oids = append(oids, usage.OID)
+		}
+	}
+
+	for _, v := range keyUsage["oids"].(*schema.Set).List() {
+		oid, err := parseOID(v.(string))
+		if err != nil {
+			return "", err
+		}
+		oids = append(oids, oid)
+	}
+
+	value, err := asn1.Marshal(oids)
+	if err != nil {
+		return "", fmt.Errorf("Error encoding extended key usage: %s", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(value), nil
+}
+
+// flattenExtendedKeyUsage describes the extended key usages of a certificate
+// in the shape of the extended_key_usage block. CAs may add usages of their
+// own, so once a block is known only the usages it asks for are read back.
+func flattenExtendedKeyUsage(cert *x509.Certificate, previous *schema.Set) []interface{} {
+	if len(cert.ExtKeyUsage) == 0 && len(cert.UnknownExtKeyUsage) == 0 {
+		return []interface{}{}
+	}
+
+	var known map[string]interface{}
+	if previous.Len() > 0 {
+		known = previous.List()[0].(map[string]interface{})
+	}
+
+	keyUsage := map[string]interface{}{}
+	for _, usage := range extendedKeyUsages {
+		keyUsage[usage.Attribute] = false
+		if known != nil && known[usage.Attribute] != true {
+			continue
+		}
+		for _, u := range cert.ExtKeyUsage {
+			if u == usage.Usage {
+				keyUsage[usage.Attribute] = true
+			}
+		}
+	}
+
+	oids := []string{}
+	for _, oid := range cert.UnknownExtKeyUsage {
+		if known != nil && !known["oids"].(*schema.Set).Contains(oid.String()) {
+			continue
+		}
+		oids = append(oids, oid.String())
+	}
+	sort.Strings(oids)
+
+	oidList := make([]interface{}, len(oids))
+	for i, oid := range oids {
+		oidList[i] = oid
+	}
+	keyUsage["oids"] = schema.NewSet(schema.HashString, oidList)
+
+	return []interface{}{keyUsage}
+}
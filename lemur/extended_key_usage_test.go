@@ -0,0 +1,57 @@
+package lemur
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestExtendedKeyUsage_roundTrip(t *testing.T) {
+	keyUsage := map[string]interface{}{
+		"use_server_authentication": true,
+		"use_client_authentication": false,
+		"use_code_signing":          false,
+		"use_email_protection":      false,
+		"use_time_stamping":         true,
+		"use_ocsp_signing":          false,
+		"oids":                      schema.NewSet(schema.HashString, []interface{}{"1.3.6.1.4.1.311.20.2.2"}),
+	}
+
+	value, err := encodeExtendedKeyUsage(keyUsage)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	template := testCertificateTemplate()
+	template.ExtraExtensions = []pkix.Extension{testExtension(t, oidExtensionExtendedKeyUsage, value)}
+	_, cert := testCertificatePEM(t, template)
+
+	if len(cert.ExtKeyUsage) != 2 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth || cert.ExtKeyUsage[1] != x509.ExtKeyUsageTimeStamping {
+		t.Fatalf("unexpected extended key usages: %v", cert.ExtKeyUsage)
+	}
+
+	flattened := flattenExtendedKeyUsage(cert, schema.NewSet(resourceEKUHash, nil))
+	if len(flattened) != 1 {
+		t.Fatalf("expected one extended_key_usage block, got %d", len(flattened))
+	}
+	if resourceEKUHash(flattened[0]) != resourceEKUHash(keyUsage) {
+		t.Fatalf("expected %#v to hash like %#v", flattened[0], keyUsage)
+	}
+}
+
+func TestParseOID(t *testing.T) {
+	oid, err := parseOID("1.3.6.1.5.5.7.3.1")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !oid.Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1}) {
+		t.Fatalf("unexpected OID: %s", oid)
+	}
+
+	if _, err := parseOID("serverAuth"); err == nil {
+		t.Fatal("expected error for invalid OID")
+	}
+}
@@ -2,15 +2,21 @@ package lemur
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/terraform/helper/hashcode"
 	"github.com/hashicorp/terraform/helper/schema"
@@ -31,42 +37,123 @@ func resourceSANHash(v interface{}) int {
 func resourceEKUHash(v interface{}) int {
 	var buf bytes.Buffer
 	m := v.(map[string]interface{})
-	buf.WriteString(fmt.Sprintf("%t", m["use_client_authentication"].(bool)))
-	buf.WriteString(fmt.Sprintf("%t", m["use_server_authentication"].(bool)))
+	for _, usage := range extendedKeyUsages {
+		if m[usage.Attribute] != nil {
+			buf.WriteString(fmt.Sprintf("%t-", m[usage.Attribute].(bool)))
+		}
+	}
+	if m["oids"] != nil {
+		oids := []string{}
+		for _, oid := range m["oids"].(*schema.Set).List() {
+			oids = append(oids, oid.(string))
+		}
+		sort.Strings(oids)
+		buf.WriteString(strings.Join(oids, ","))
+	}
 	return hashcode.String(buf.String())
 }
 
-func getCertificate(d *schema.ResourceData, config Config) (map[string]interface{}, error) {
-	client := &http.Client{}
-	name := d.Get("name").(string)
+// requestError is returned when Lemur answers with a non-2xx status code.
+type requestError struct {
+	URL        string
+	StatusCode int
+	Body       string
+}
 
-	findExistingURL := config.Host + "/api/1/certificates?filter=name;" + name
-	findExisting, err := http.NewRequest("GET", findExistingURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("Error creating request: %s", findExistingURL)
+func (e *requestError) Error() string {
+	return fmt.Sprintf("HTTP request error. Response code: %d \n%s", e.StatusCode, e.Body)
+}
+
+// Message returns the message from Lemur's JSON error body, falling back to
+// the raw body.
+func (e *requestError) Message() string {
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(e.Body), &body); err == nil {
+		if message := stringValue(body, "message"); message != "" {
+			return message
+		}
+	}
+	return e.Body
+}
+
+func isNotFound(err error) bool {
+	if err, ok := err.(*requestError); ok {
+		return err.StatusCode == 404
+	}
+	return false
+}
+
+// request sends an authenticated request to the Lemur API and decodes the
+// JSON response into responseData. A nil requestData sends no body.
+func (c Config) request(method string, url string, requestData interface{}, responseData interface{}) error {
+	var body io.Reader
+	if requestData != nil {
+		jsonValue, _ := json.Marshal(requestData)
+		body = bytes.NewBuffer(jsonValue)
 	}
-	findExisting.Header.Set("Authorization", "bearer "+config.Token)
 
-	findExistingResponse, err := client.Do(findExisting)
+	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		return nil, fmt.Errorf("Error during making a request: %s", findExistingURL)
+		return fmt.Errorf("Error creating request: %s", url)
+	}
+	if c.ctx != nil {
+		req = req.WithContext(c.ctx)
+	}
+	req.Header.Set("Authorization", "bearer "+c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
-	defer findExistingResponse.Body.Close()
+	client := c.Client
+	if client == nil {
+		client = &http.Client{}
+	}
 
-	if findExistingResponse.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP request error. Response code: %d", findExistingResponse.StatusCode)
+	resp, err := client.Do(req)
+	if err != nil {
+		if c.ctx != nil && c.ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("Timeout while waiting for a response: %s", url)
+		}
+		if err, ok := err.(net.Error); ok && err.Timeout() {
+			return fmt.Errorf("Request timed out: %s", url)
+		}
+		return fmt.Errorf("Error during making a request: %s", url)
 	}
 
-	responseBytes, err := ioutil.ReadAll(findExistingResponse.Body)
+	defer resp.Body.Close()
+
+	responseBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("Error while reading response body. %s", err)
+		return fmt.Errorf("Error while reading response body. %s", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &requestError{
+			URL:        url,
+			StatusCode: resp.StatusCode,
+			Body:       string(responseBytes[:]),
+		}
+	}
+
+	if responseData != nil && len(responseBytes) > 0 {
+		err = json.Unmarshal(responseBytes, responseData)
+		if err != nil {
+			return fmt.Errorf("Error while reading response body. %s", err)
+		}
 	}
 
+	return nil
+}
+
+func getCertificate(d *schema.ResourceData, config Config) (map[string]interface{}, error) {
+	name := d.Get("name").(string)
+
+	findExistingURL := config.Host + "/api/1/certificates?filter=name;" + name
+
 	var certificatesResponse map[string]interface{}
-	err = json.Unmarshal(responseBytes, &certificatesResponse)
+	err := config.request("GET", findExistingURL, nil, &certificatesResponse)
 	if err != nil {
-		return nil, fmt.Errorf("Error while reading response body. %s", err)
+		return nil, err
 	}
 
 	totalResults := certificatesResponse["total"].(float64)
@@ -74,7 +161,7 @@ func getCertificate(d *schema.ResourceData, config Config) (map[string]interface
 		items := certificatesResponse["items"].([]interface{})
 		for _, item := range items {
 			itemMap := item.(map[string]interface{})
-			if itemMap["active"].(bool) && strings.HasPrefix(name, itemMap["name"].(string)) {
+			if itemMap["active"].(bool) && strings.HasPrefix(name, itemMap["name"].(string)) && certificateInvalidReason(itemMap) == "" {
 				return itemMap, nil
 			}
 		}
@@ -83,36 +170,160 @@ func getCertificate(d *schema.ResourceData, config Config) (map[string]interface
 	return nil, nil
 }
 
-func getPublicCertificateData(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
-	client := &http.Client{}
+// listPageSize is the number of items requested per page from Lemur's
+// paginated list endpoints.
+const listPageSize = 100
+
+// listItems walks every page of a paginated Lemur list endpoint, e.g.
+// /certificates or /authorities, with an optional filter such as "cn;example".
+func listItems(path string, filter string, config Config) ([]map[string]interface{}, error) {
+	items := []map[string]interface{}{}
+
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("page", strconv.Itoa(page))
+		query.Set("count", strconv.Itoa(listPageSize))
+		if filter != "" {
+			query.Set("filter", filter)
+		}
+		listURL := config.Host + "/api/1/" + path + "?" + query.Encode()
+
+		var listResponse map[string]interface{}
+		err := config.request("GET", listURL, nil, &listResponse)
+		if err != nil {
+			return nil, err
+		}
+
+		pageItems, _ := listResponse["items"].([]interface{})
+		for _, item := range pageItems {
+			if itemMap, ok := item.(map[string]interface{}); ok {
+				items = append(items, itemMap)
+			}
+		}
 
+		total, _ := listResponse["total"].(float64)
+		if len(pageItems) < listPageSize || len(items) >= int(total) {
+			return items, nil
+		}
+	}
+}
+
+// getCertificateByID fetches a single certificate, returning nil when Lemur
+// no longer knows about it.
+func getCertificateByID(certificateID int, config Config) (map[string]interface{}, error) {
 	url := config.Host + "/api/1/certificates/" + strconv.Itoa(certificateID)
-	req, err := http.NewRequest("GET", url, nil)
+
+	var certificate map[string]interface{}
+	err := config.request("GET", url, nil, &certificate)
+	if isNotFound(err) {
+		return nil, nil
+	}
 	if err != nil {
-		return "", "", fmt.Errorf("Error creating request: %s", url)
+		return nil, err
 	}
-	req.Header.Set("Authorization", "bearer "+config.Token)
 
-	resp, err := client.Do(req)
+	return certificate, nil
+}
+
+// certificateEditFields lists the attributes Lemur expects when editing a
+// certificate. They are copied from the current certificate so that an edit
+// only changes what it means to.
+var certificateEditFields = []string{
+	"owner",
+	"description",
+	"notify",
+	"rotation",
+	"rotationPolicy",
+	"destinations",
+	"notifications",
+	"roles",
+	"replaces",
+}
+
+// certificateLocks serializes the read-modify-writes of each certificate, as
+// Terraform applies resources linking to the same certificate in parallel.
+var certificateLocks = struct {
+	sync.Mutex
+	locks map[int]*sync.Mutex
+}{locks: map[int]*sync.Mutex{}}
+
+// lockCertificate locks the certificate with the given ID and returns the
+// function unlocking it.
+func lockCertificate(certificateID int) func() {
+	certificateLocks.Lock()
+	lock, ok := certificateLocks.locks[certificateID]
+	if !ok {
+		lock = &sync.Mutex{}
+		certificateLocks.locks[certificateID] = lock
+	}
+	certificateLocks.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// updateCertificate performs a read-modify-write of a certificate through
+// PUT /certificates/{id}.
+func updateCertificate(certificateID int, config Config, modify func(map[string]interface{}) error) (map[string]interface{}, error) {
+	defer lockCertificate(certificateID)()
+
+	certificate, err := getCertificateByID(certificateID, config)
 	if err != nil {
-		return "", "", fmt.Errorf("Error during making a request: %s", url)
+		return nil, err
+	}
+	if certificate == nil {
+		return nil, fmt.Errorf("Certificate %d does not exist", certificateID)
 	}
 
-	defer resp.Body.Close()
+	requestData := map[string]interface{}{}
+	for _, field := range certificateEditFields {
+		if v, ok := certificate[field]; ok {
+			requestData[field] = v
+		}
+	}
 
-	if resp.StatusCode != 200 {
-		return "", "", fmt.Errorf("HTTP request error. Response code: %d", resp.StatusCode)
+	if err := modify(requestData); err != nil {
+		return nil, err
 	}
 
-	responseBytes, err := ioutil.ReadAll(resp.Body)
+	url := config.Host + "/api/1/certificates/" + strconv.Itoa(certificateID)
+
+	var updated map[string]interface{}
+	err = config.request("PUT", url, requestData, &updated)
 	if err != nil {
-		return "", "", fmt.Errorf("Error while reading response body. %s", err)
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// certificateInvalidReason explains why a certificate can no longer be used,
+// or returns an empty string while it is still valid.
+func certificateInvalidReason(certificate map[string]interface{}) string {
+	if revoked, ok := certificate["revoked"].(bool); ok && revoked {
+		return "revoked"
+	}
+	status := stringValue(certificate, "status")
+	if status == "revoked" || status == "expired" {
+		return status
+	}
+	if active, ok := certificate["active"].(bool); ok && !active {
+		return "deactivated"
 	}
+	if notAfter, err := time.Parse(time.RFC3339, stringValue(certificate, "notAfter")); err == nil && notAfter.Before(time.Now()) {
+		return "expired"
+	}
+
+	return ""
+}
+
+func getPublicCertificateData(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
+	url := config.Host + "/api/1/certificates/" + strconv.Itoa(certificateID)
 
 	var certificatesResponse map[string]interface{}
-	err = json.Unmarshal(responseBytes, &certificatesResponse)
+	err := config.request("GET", url, nil, &certificatesResponse)
 	if err != nil {
-		return "", "", fmt.Errorf("Error while reading response body. %s", err)
+		return "", "", err
 	}
 
 	chain := ""
@@ -124,248 +335,283 @@ func getPublicCertificateData(certificateID int, d *schema.ResourceData, config
 }
 
 func getPrivateCertificateData(certificateID int, d *schema.ResourceData, config Config) (string, error) {
-	client := &http.Client{}
-
 	url := config.Host + "/api/1/certificates/" + strconv.Itoa(certificateID) + "/key"
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("Error creating request: %s", url)
-	}
-	req.Header.Set("Authorization", "bearer "+config.Token)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("Error during making a request: %s", url)
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("HTTP request error. Response code: %d", resp.StatusCode)
-	}
-
-	responseBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("Error while reading response body. %s", err)
-	}
 
 	var keyReponse map[string]interface{}
-	err = json.Unmarshal(responseBytes, &keyReponse)
+	err := config.request("GET", url, nil, &keyReponse)
 	if err != nil {
-		return "", fmt.Errorf("Error while reading response body. %s", err)
+		return "", err
 	}
 
 	return keyReponse["key"].(string), nil
 }
 
-func exportCertificatePKCS(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
-	client := &http.Client{}
-
+// exportCertificate runs a Lemur export plugin against a certificate and
+// returns the base64 encoded data along with the passphrase protecting it.
+func exportCertificate(certificateID int, slug string, pluginOptions []map[string]string, config Config) (string, string, error) {
 	url := config.Host + "/api/1/certificates/" + strconv.Itoa(certificateID) + "/export"
 
-	password := newPassword(20)
-
 	requestData := map[string]interface{}{
 		"plugin": map[string]interface{}{
-			"pluginOptions": []map[string]string{
-				map[string]string{
-					"name":  "type",
-					"value": "PKCS12 (.p12)",
-				},
-				map[string]string{
-					"name":  "passphrase",
-					"value": password,
-				},
-			},
-			"slug": "openssl-export",
+			"pluginOptions": pluginOptions,
+			"slug":          slug,
 		},
 	}
-	jsonValue, _ := json.Marshal(requestData)
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonValue))
+	var exportResponse map[string]interface{}
+	err := config.request("POST", url, requestData, &exportResponse)
 	if err != nil {
-		return "", "", fmt.Errorf("Error creating request: %s", url)
+		if err, ok := err.(*requestError); ok {
+			return "", "", fmt.Errorf("Error exporting certificate %d with plugin %s: %s", certificateID, slug, err.Message())
+		}
+		return "", "", fmt.Errorf("Error exporting certificate %d with plugin %s: %s", certificateID, slug, err)
 	}
-	req.Header.Set("Authorization", "bearer "+config.Token)
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", "", fmt.Errorf("Error during making a request: %s", url)
+	data := stringValue(exportResponse, "data")
+	if data == "" {
+		return "", "", fmt.Errorf("Error exporting certificate %d with plugin %s: no data returned", certificateID, slug)
 	}
 
-	defer resp.Body.Close()
+	return data, stringValue(exportResponse, "passphrase"), nil
+}
 
-	responseBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("Error while reading response body. %s", err)
+// exportPassphrase returns the first configured or previously generated
+// passphrase among the given attributes, generating one if none is set.
+func exportPassphrase(d *schema.ResourceData, attributes ...string) string {
+	for _, attribute := range attributes {
+		if v, ok := d.GetOk(attribute); ok {
+			return v.(string)
+		}
 	}
+	return newPassword(20)
+}
 
-	if resp.StatusCode != 200 {
-		return "", "", fmt.Errorf("HTTP request error. Response code: %d \n%s", resp.StatusCode, string(responseBytes[:]))
-	}
+func exportCertificatePKCS(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
+	password := exportPassphrase(d, "pkcs12_passphrase", "pkcs_passphrase")
 
-	var exportResponse map[string]interface{}
-	err = json.Unmarshal(responseBytes, &exportResponse)
-	if err != nil {
-		return "", "", fmt.Errorf("Error while reading response body. %s", err)
+	pluginOptions := []map[string]string{
+		map[string]string{
+			"name":  "type",
+			"value": "PKCS12 (.p12)",
+		},
+		map[string]string{
+			"name":  "passphrase",
+			"value": password,
+		},
 	}
 
-	return exportResponse["data"].(string), exportResponse["passphrase"].(string), nil
+	data, _, err := exportCertificate(certificateID, "openssl-export", pluginOptions, config)
+	return data, password, err
 }
 
 func exportCertificateCRT(certificateID int, d *schema.ResourceData, config Config) (string, error) {
-	client := &http.Client{}
-
-	url := config.Host + "/api/1/certificates/" + strconv.Itoa(certificateID) + "/export"
-
-	requestData := map[string]interface{}{
-		"plugin": map[string]interface{}{
-			"pluginOptions": []map[string]string{
-				map[string]string{
-					"name":  "type",
-					"value": "CRT (.crt)",
-				},
-			},
-			"slug": "openssl-export",
+	pluginOptions := []map[string]string{
+		map[string]string{
+			"name":  "type",
+			"value": "CRT (.crt)",
 		},
 	}
-	jsonValue, _ := json.Marshal(requestData)
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonValue))
-	if err != nil {
-		return "", fmt.Errorf("Error creating request: %s", url)
-	}
-	req.Header.Set("Authorization", "bearer "+config.Token)
-	req.Header.Set("Content-Type", "application/json")
+	data, _, err := exportCertificate(certificateID, "openssl-export", pluginOptions, config)
+	return data, err
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("Error during making a request: %s", url)
-	}
+func exportCertificateJKSKeystore(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
+	password := exportPassphrase(d, "jks_keystore_passphrase")
 
-	defer resp.Body.Close()
+	data, _, err := exportCertificate(certificateID, "java-keystore-jks", jksPluginOptions(password, d), config)
+	return data, password, err
+}
 
-	responseBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("Error while reading response body. %s", err)
-	}
+func exportCertificateJKSTruststore(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
+	password := exportPassphrase(d, "jks_truststore_passphrase")
+
+	data, _, err := exportCertificate(certificateID, "java-truststore-jks", jksPluginOptions(password, d), config)
+	return data, password, err
+}
 
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("HTTP request error. Response code: %d \n%s", resp.StatusCode, string(responseBytes[:]))
+func jksPluginOptions(password string, d *schema.ResourceData) []map[string]string {
+	pluginOptions := []map[string]string{
+		map[string]string{
+			"name":  "passphrase",
+			"value": password,
+		},
 	}
 
-	var exportResponse map[string]interface{}
-	err = json.Unmarshal(responseBytes, &exportResponse)
-	if err != nil {
-		return "", fmt.Errorf("Error while reading response body. %s", err)
+	if alias, ok := d.GetOk("jks_alias"); ok {
+		pluginOptions = append(pluginOptions, map[string]string{
+			"name":  "alias",
+			"value": alias.(string),
+		})
 	}
 
-	return exportResponse["data"].(string), nil
+	return pluginOptions
 }
 
-func exportCertificateJKSKeystore(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
-	client := &http.Client{}
+// certificateExportFormat ties an entry of export_formats to its export
+// function and the attributes it populates.
+type certificateExportFormat struct {
+	Export              func(int, *schema.ResourceData, Config) (string, string, error)
+	DataAttribute       string
+	PassphraseAttribute string
 
-	url := config.Host + "/api/1/certificates/" + strconv.Itoa(certificateID) + "/export"
+	// Options lists the arguments which require a new export when changed.
+	Options []string
 
-	password := newPassword(20)
+	// PrivateKey marks formats that contain the private key.
+	PrivateKey bool
 
-	requestData := map[string]interface{}{
-		"plugin": map[string]interface{}{
-			"pluginOptions": []map[string]string{
-				map[string]string{
-					"name":  "passphrase",
-					"value": password,
-				},
-			},
-			"slug": "java-keystore-jks",
-		},
-	}
-	jsonValue, _ := json.Marshal(requestData)
+	// EncryptedAttribute receives the data instead of DataAttribute when
+	// pgp_key is set.
+	EncryptedAttribute string
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonValue))
-	if err != nil {
-		return "", "", fmt.Errorf("Error creating request: %s", url)
-	}
-	req.Header.Set("Authorization", "bearer "+config.Token)
-	req.Header.Set("Content-Type", "application/json")
+var certificateExportFormats = map[string]certificateExportFormat{
+	"pkcs12": certificateExportFormat{
+		Export:              exportCertificatePKCS,
+		DataAttribute:       "pkcs_base_64",
+		PassphraseAttribute: "pkcs12_passphrase",
+		Options:             []string{"pkcs12_passphrase"},
+		PrivateKey:          true,
+		EncryptedAttribute:  "encrypted_pkcs12",
+	},
+	"jks_keystore": certificateExportFormat{
+		Export:              exportCertificateJKSKeystore,
+		DataAttribute:       "jks_keystore_base_64",
+		PassphraseAttribute: "jks_keystore_passphrase",
+		Options:             []string{"jks_keystore_passphrase", "jks_alias"},
+		PrivateKey:          true,
+		EncryptedAttribute:  "encrypted_jks_keystore",
+	},
+	"jks_truststore": certificateExportFormat{
+		Export:              exportCertificateJKSTruststore,
+		DataAttribute:       "jks_truststore_base_64",
+		PassphraseAttribute: "jks_truststore_passphrase",
+		Options:             []string{"jks_truststore_passphrase", "jks_alias"},
+	},
+	"pkcs7": certificateExportFormat{
+		Export:        exportCertificatePKCS7,
+		DataAttribute: "pkcs7_base_64",
+	},
+	"der": certificateExportFormat{
+		Export:        exportCertificateDER,
+		DataAttribute: "der_base_64",
+	},
+	"fullchain_pem": certificateExportFormat{
+		Export:        exportCertificateFullChainPEM,
+		DataAttribute: "pem_full_chain",
+	},
+	"pkcs8_encrypted": certificateExportFormat{
+		Export:              exportCertificatePKCS8Encrypted,
+		DataAttribute:       "pkcs8_encrypted_private_key",
+		PassphraseAttribute: "pkcs8_passphrase",
+		Options:             []string{"pkcs8_passphrase"},
+		PrivateKey:          true,
+		EncryptedAttribute:  "encrypted_pkcs8_private_key",
+	},
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", "", fmt.Errorf("Error during making a request: %s", url)
+func certificateExportFormatNames() []string {
+	names := make([]string, 0, len(certificateExportFormats))
+	for name := range certificateExportFormats {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	defer resp.Body.Close()
+// exportCertificateFormats runs the exports for the given formats and stores
+// the results.
+func exportCertificateFormats(certificateID int, formats *schema.Set, d *schema.ResourceData, config Config) error {
+	for _, name := range certificateExportFormatNames() {
+		if !formats.Contains(name) {
+			continue
+		}
 
-	responseBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("Error while reading response body. %s", err)
-	}
+		format := certificateExportFormats[name]
+		if format.PrivateKey && !privateKeyInState(d) {
+			continue
+		}
 
-	if resp.StatusCode != 200 {
-		return "", "", fmt.Errorf("HTTP request error. Response code: %d \n%s", resp.StatusCode, string(responseBytes[:]))
-	}
+		data, passphrase, err := format.Export(certificateID, d, config)
+		if err != nil {
+			return err
+		}
 
-	var exportResponse map[string]interface{}
-	err = json.Unmarshal(responseBytes, &exportResponse)
-	if err != nil {
-		return "", "", fmt.Errorf("Error while reading response body. %s", err)
+		d.Set(format.DataAttribute, data)
+		if format.PassphraseAttribute != "" {
+			d.Set(format.PassphraseAttribute, passphrase)
+		}
 	}
 
-	return exportResponse["data"].(string), exportResponse["passphrase"].(string), nil
+	return nil
 }
 
-func exportCertificateJKSTruststore(certificateID int, d *schema.ResourceData, config Config) (string, string, error) {
-	client := &http.Client{}
-
-	url := config.Host + "/api/1/certificates/" + strconv.Itoa(certificateID) + "/export"
+// changedCertificateExports returns the requested formats which were just
+// added or whose options changed.
+func changedCertificateExports(d *schema.ResourceData) *schema.Set {
+	o, n := d.GetChange("export_formats")
+	changed := n.(*schema.Set).Difference(o.(*schema.Set))
 
-	password := newPassword(20)
-
-	requestData := map[string]interface{}{
-		"plugin": map[string]interface{}{
-			"pluginOptions": []map[string]string{
-				map[string]string{
-					"name":  "passphrase",
-					"value": password,
-				},
-			},
-			"slug": "java-truststore-jks",
-		},
+	for name, format := range certificateExportFormats {
+		if !n.(*schema.Set).Contains(name) {
+			continue
+		}
+		for _, option := range format.Options {
+			if d.HasChange(option) {
+				changed.Add(name)
+			}
+		}
 	}
-	jsonValue, _ := json.Marshal(requestData)
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonValue))
-	if err != nil {
-		return "", "", fmt.Errorf("Error creating request: %s", url)
-	}
-	req.Header.Set("Authorization", "bearer "+config.Token)
-	req.Header.Set("Content-Type", "application/json")
+	return changed
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", "", fmt.Errorf("Error during making a request: %s", url)
+// clearCertificateExports empties the data of every format that is not
+// requested anymore, or that holds a private key which must stay out of
+// state. Passphrases are kept so they can be reused later.
+func clearCertificateExports(formats *schema.Set, d *schema.ResourceData) {
+	for name, format := range certificateExportFormats {
+		if !formats.Contains(name) || (format.PrivateKey && !privateKeyInState(d)) {
+			d.Set(format.DataAttribute, "")
+			if format.EncryptedAttribute != "" {
+				d.Set(format.EncryptedAttribute, "")
+			}
+		}
 	}
+}
 
-	defer resp.Body.Close()
+var privateKeyHandlingModes = []string{"state", "omit", "destination_only"}
 
-	responseBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("Error while reading response body. %s", err)
-	}
+// resourceGetter reads attributes from a schema.ResourceData or, while
+// planning, from a schema.ResourceDiff.
+type resourceGetter interface {
+	Get(key string) interface{}
+}
 
-	if resp.StatusCode != 200 {
-		return "", "", fmt.Errorf("HTTP request error. Response code: %d \n%s", resp.StatusCode, string(responseBytes[:]))
-	}
+// privateKeyInState reports whether private_key_handling allows the private
+// key, and the exports containing it, to be stored in state. State written
+// before the setting existed has no value and keeps the old behaviour.
+func privateKeyInState(d resourceGetter) bool {
+	mode := d.Get("private_key_handling").(string)
+	return mode == "" || mode == "state"
+}
 
-	var exportResponse map[string]interface{}
-	err = json.Unmarshal(responseBytes, &exportResponse)
-	if err != nil {
-		return "", "", fmt.Errorf("Error while reading response body. %s", err)
+// validatePrivateKeyHandling rejects export formats which would put the
+// private key in state when private_key_handling keeps it out.
+func validatePrivateKeyHandling(d resourceGetter) error {
+	if privateKeyInState(d) {
+		return nil
 	}
 
-	return exportResponse["data"].(string), exportResponse["passphrase"].(string), nil
+	formats := d.Get("export_formats").(*schema.Set)
+	for _, name := range certificateExportFormatNames() {
+		if formats.Contains(name) && certificateExportFormats[name].PrivateKey {
+			return fmt.Errorf("Export format %s contains the private key, which private_key_handling = %q keeps out of state",
+				name, d.Get("private_key_handling").(string))
+		}
+	}
+	return nil
 }
 
 var passwordChars = []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789")
@@ -0,0 +1,458 @@
+package lemur
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// testLemur is a minimal stand-in for the Lemur API, serving certificates
+// and authorities from memory and recording what the provider sends.
+type testLemur struct {
+	*httptest.Server
+	t *testing.T
+
+	mu           sync.Mutex
+	certificates map[int]map[string]interface{}
+	keys         map[int]string
+	authorities  map[int]map[string]interface{}
+
+	// issued is added as certificate 1 when a certificate is created.
+	issued    map[string]interface{}
+	issuedKey string
+
+	created      map[string]interface{}
+	updates      []map[string]interface{}
+	exports      []map[string]interface{}
+	exportErrors map[string]string
+
+	// lists holds the query of every listing request.
+	lists []url.Values
+}
+
+var (
+	testLemurCertificatePath = regexp.MustCompile(`^/api/1/certificates/(\d+)(/key|/export)?$`)
+	testLemurAuthorityPath   = regexp.MustCompile(`^/api/1/authorities/(\d+)$`)
+)
+
+func newTestLemur(t *testing.T) *testLemur {
+	l := &testLemur{
+		t:            t,
+		certificates: map[int]map[string]interface{}{},
+		keys:         map[int]string{},
+		authorities:  map[int]map[string]interface{}{},
+		exportErrors: map[string]string{},
+	}
+	l.Server = httptest.NewServer(http.HandlerFunc(l.serveHTTP))
+	return l
+}
+
+// testLemurKey returns a new private key along with its PEM encoding.
+func testLemurKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+}
+
+// testLemurCertificate returns a valid certificate as listed by Lemur along
+// with its private key.
+func testLemurCertificate(t *testing.T, id int) (map[string]interface{}, string) {
+	key, keyPEM := testLemurKey(t)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(int64(id)),
+		Subject:               pkix.Name{CommonName: "example.com"},
+		NotBefore:             time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return map[string]interface{}{
+		"id":         float64(id),
+		"name":       "example.com",
+		"commonName": "example.com",
+		"owner":      "team@example.com",
+		"active":     true,
+		"status":     "valid",
+		"authority":  map[string]interface{}{"name": "ca"},
+		"body":       string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		"chain":      "",
+	}, keyPEM
+}
+
+func (l *testLemur) config() Config {
+	return Config{Host: l.URL}
+}
+
+func (l *testLemur) addCertificate(id int) map[string]interface{} {
+	certificate, key := testLemurCertificate(l.t, id)
+	l.certificates[id] = certificate
+	l.keys[id] = key
+	return certificate
+}
+
+// addAuthority adds a root authority whose certificate is also served as a
+// certificate, under the authority's ID plus 100.
+func (l *testLemur) addAuthority(id int, name string) map[string]interface{} {
+	cert, _ := testAuthorityCertificate(l.t, name, int64(id), nil, nil)
+	certificate := map[string]interface{}{
+		"id":         float64(100 + id),
+		"name":       name,
+		"commonName": name,
+		"active":     true,
+		"status":     "valid",
+		"body":       testPEM(cert),
+		"chain":      "",
+	}
+	l.certificates[100+id] = certificate
+
+	authority := map[string]interface{}{
+		"id":                   float64(id),
+		"name":                 name,
+		"owner":                "team@example.com",
+		"active":               true,
+		"plugin":               map[string]interface{}{"slug": "cfssl-issuer"},
+		"authorityCertificate": certificate,
+	}
+	l.authorities[id] = authority
+	return authority
+}
+
+func (l *testLemur) exportedSlugs() []string {
+	slugs := []string{}
+	for _, plugin := range l.exports {
+		slugs = append(slugs, plugin["slug"].(string))
+	}
+	return slugs
+}
+
+func (l *testLemur) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var body map[string]interface{}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	write := func(status int, v interface{}) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+
+	if r.URL.Path == "/api/1/authorities" {
+		write(http.StatusOK, l.list(l.authorities, r.URL.Query()))
+		return
+	}
+	if match := testLemurAuthorityPath.FindStringSubmatch(r.URL.Path); match != nil {
+		id, _ := strconv.Atoi(match[1])
+		if authority, ok := l.authorities[id]; ok {
+			write(http.StatusOK, authority)
+		} else {
+			write(http.StatusNotFound, map[string]interface{}{"message": "not found"})
+		}
+		return
+	}
+
+	if r.URL.Path == "/api/1/certificates" {
+		switch r.Method {
+		case "GET":
+			write(http.StatusOK, l.list(l.certificates, r.URL.Query()))
+		case "POST":
+			l.created = body
+			l.certificates[1] = l.issued
+			l.keys[1] = l.issuedKey
+			write(http.StatusOK, l.issued)
+		}
+		return
+	}
+
+	match := testLemurCertificatePath.FindStringSubmatch(r.URL.Path)
+	if match == nil {
+		http.NotFound(w, r)
+		return
+	}
+	id, _ := strconv.Atoi(match[1])
+	certificate, ok := l.certificates[id]
+	if !ok {
+		write(http.StatusNotFound, map[string]interface{}{"message": "not found"})
+		return
+	}
+
+	switch {
+	case match[2] == "/key":
+		write(http.StatusOK, map[string]interface{}{"key": l.keys[id]})
+	case match[2] == "/export":
+		plugin := body["plugin"].(map[string]interface{})
+		l.exports = append(l.exports, plugin)
+		slug := plugin["slug"].(string)
+		if message, ok := l.exportErrors[slug]; ok {
+			write(http.StatusInternalServerError, map[string]interface{}{"message": message})
+			return
+		}
+		write(http.StatusOK, map[string]interface{}{
+			"data": base64.StdEncoding.EncodeToString([]byte(slug)),
+		})
+	case r.Method == "PUT":
+		l.updates = append(l.updates, body)
+		for k, v := range body {
+			certificate[k] = v
+		}
+		write(http.StatusOK, certificate)
+	default:
+		write(http.StatusOK, certificate)
+	}
+}
+
+// list serves a page of objects ordered by ID, applying a "field;value"
+// filter the way Lemur does: a case-insensitive substring match, where a
+// name also matches the common name and domains.
+func (l *testLemur) list(objects map[int]map[string]interface{}, query url.Values) map[string]interface{} {
+	l.lists = append(l.lists, query)
+
+	field, value := "", ""
+	if parts := strings.SplitN(query.Get("filter"), ";", 2); len(parts) == 2 {
+		field, value = parts[0], strings.ToLower(parts[1])
+	}
+	matches := func(object map[string]interface{}) bool {
+		if field == "" {
+			return true
+		}
+		values := []string{}
+		switch field {
+		case "cn":
+			values = append(values, stringValue(object, "commonName"))
+		case "name":
+			values = append(values, stringValue(object, "name"), stringValue(object, "commonName"))
+			values = append(values, certificateDomains(object)...)
+		default:
+			values = append(values, stringValue(object, field))
+		}
+		for _, v := range values {
+			if strings.Contains(strings.ToLower(v), value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ids := []int{}
+	for id, object := range objects {
+		if matches(object) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	// Lemur serves the first 10 objects unless asked otherwise.
+	page, count := 1, 10
+	if v, err := strconv.Atoi(query.Get("page")); err == nil {
+		page = v
+	}
+	if v, err := strconv.Atoi(query.Get("count")); err == nil {
+		count = v
+	}
+	start, end := (page-1)*count, page*count
+	if start > len(ids) {
+		start = len(ids)
+	}
+	if end > len(ids) {
+		end = len(ids)
+	}
+
+	items := []interface{}{}
+	for _, id := range ids[start:end] {
+		items = append(items, objects[id])
+	}
+	return map[string]interface{}{"items": items, "total": len(ids)}
+}
+
+func TestCertificateInvalidReason(t *testing.T) {
+	cases := []struct {
+		Certificate map[string]interface{}
+		Expected    string
+	}{
+		{
+			Certificate: map[string]interface{}{"active": true, "status": "valid", "notAfter": "2999-01-01T00:00:00+00:00"},
+			Expected:    "",
+		},
+		{
+			Certificate: map[string]interface{}{"active": true, "revoked": true},
+			Expected:    "revoked",
+		},
+		{
+			Certificate: map[string]interface{}{"active": false, "status": "revoked"},
+			Expected:    "revoked",
+		},
+		{
+			Certificate: map[string]interface{}{"active": false, "status": "valid"},
+			Expected:    "deactivated",
+		},
+		{
+			Certificate: map[string]interface{}{"active": true, "notAfter": "2017-01-01T00:00:00+00:00"},
+			Expected:    "expired",
+		},
+	}
+
+	for i, tc := range cases {
+		if actual := certificateInvalidReason(tc.Certificate); actual != tc.Expected {
+			t.Errorf("%d: expected %q, got %q", i, tc.Expected, actual)
+		}
+	}
+}
+
+func TestPrivateKeyHandling(t *testing.T) {
+	d := resourceLemurCertificate().TestResourceData()
+	d.SetId("1")
+	d.Set("export_formats", schema.NewSet(schema.HashString, []interface{}{"pkcs12", "der"}))
+	d.Set("pkcs_base_64", "key")
+	d.Set("der_base_64", "certificate")
+
+	if err := validatePrivateKeyHandling(d); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	d.Set("private_key_handling", "omit")
+	if err := validatePrivateKeyHandling(d); err == nil {
+		t.Fatal("expected error for pkcs12 with private_key_handling = omit")
+	}
+
+	clearCertificateExports(d.Get("export_formats").(*schema.Set), d)
+	if v := d.Get("pkcs_base_64").(string); v != "" {
+		t.Errorf("expected pkcs_base_64 to be cleared, got %q", v)
+	}
+	if v := d.Get("der_base_64").(string); v != "certificate" {
+		t.Errorf("expected der_base_64 to be kept, got %q", v)
+	}
+
+	// Setting a set over a previous value keeps the old elements, so the
+	// last case starts from fresh data.
+	d = resourceLemurCertificate().TestResourceData()
+	d.Set("private_key_handling", "omit")
+	d.Set("export_formats", schema.NewSet(schema.HashString, []interface{}{"der"}))
+	if err := validatePrivateKeyHandling(d); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestResourceLemurCertificateRead_exportFormats(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.addCertificate(1)
+
+	d := resourceLemurCertificate().TestResourceData()
+	d.SetId("1")
+	d.Set("name", "example.com")
+	d.Set("export_formats", schema.NewSet(schema.HashString, []interface{}{"jks_truststore", "der"}))
+	state := d.State()
+
+	refreshed, err := resourceLemurCertificate().Refresh(state, lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if slugs := lemur.exportedSlugs(); len(slugs) != 1 || slugs[0] != "java-truststore-jks" {
+		t.Fatalf("expected only the requested export to run, got %v", slugs)
+	}
+	if refreshed.Attributes["jks_truststore_base_64"] == "" || refreshed.Attributes["der_base_64"] == "" {
+		t.Fatalf("expected the requested exports in state, got %v", refreshed.Attributes)
+	}
+	if refreshed.Attributes["pkcs_base_64"] != "" {
+		t.Fatal("expected pkcs12 not to be exported")
+	}
+
+	lemur.exports = nil
+	lemur.exportErrors["java-truststore-jks"] = "Keytool failed"
+	_, err = resourceLemurCertificate().Refresh(state, lemur.config())
+	if err == nil {
+		t.Fatal("expected a failed export to fail Read")
+	}
+	if !strings.Contains(err.Error(), "java-truststore-jks") || !strings.Contains(err.Error(), "Keytool failed") {
+		t.Fatalf("expected the plugin and Lemur's message in the error, got: %s", err)
+	}
+}
+
+func TestResourceLemurCertificateRead_exportPassphrases(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.addCertificate(1)
+
+	d := resourceLemurCertificate().TestResourceData()
+	d.SetId("1")
+	d.Set("name", "example.com")
+	d.Set("export_formats", schema.NewSet(schema.HashString, []interface{}{"pkcs12", "jks_keystore"}))
+	d.Set("jks_keystore_passphrase", "user-supplied")
+	d.Set("jks_alias", "web")
+
+	state, err := resourceLemurCertificate().Refresh(d.State(), lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	options := map[string]map[string]string{}
+	for _, plugin := range lemur.exports {
+		slug := plugin["slug"].(string)
+		options[slug] = map[string]string{}
+		for _, option := range plugin["pluginOptions"].([]interface{}) {
+			option := option.(map[string]interface{})
+			options[slug][option["name"].(string)] = option["value"].(string)
+		}
+	}
+	if v := options["java-keystore-jks"]["passphrase"]; v != "user-supplied" {
+		t.Fatalf("expected the configured passphrase to reach the plugin, got %q", v)
+	}
+	if v := options["java-keystore-jks"]["alias"]; v != "web" {
+		t.Fatalf("expected jks_alias to reach the plugin, got %q", v)
+	}
+
+	generated := state.Attributes["pkcs12_passphrase"]
+	if generated == "" || options["openssl-export"]["passphrase"] != generated {
+		t.Fatalf("expected the generated passphrase to be stored, got %q", generated)
+	}
+
+	// Exporting again, e.g. for a rotated certificate, reuses the passphrase
+	// from state.
+	lemur.exports = nil
+	delete(state.Attributes, "certificate_id")
+	state, err = resourceLemurCertificate().Refresh(state, lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(lemur.exports) != 2 {
+		t.Fatalf("expected both formats to be exported again, got %v", lemur.exportedSlugs())
+	}
+	if v := state.Attributes["pkcs12_passphrase"]; v != generated {
+		t.Fatalf("expected the passphrase to be reused, got %q instead of %q", v, generated)
+	}
+}
@@ -0,0 +1,186 @@
+package lemur
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+
+	// openpgp falls back to RIPEMD-160 for keys without hash preferences.
+	_ "golang.org/x/crypto/ripemd160"
+)
+
+const keybaseLookupURL = "https://keybase.io/_/api/1.0/user/lookup.json"
+
+// parsePGPKey reads a public key given either ASCII armored or as base64
+// encoded binary, the form used by other providers' pgp_key arguments.
+func parsePGPKey(key string) (*openpgp.Entity, error) {
+	if strings.Contains(key, "-----BEGIN PGP PUBLIC KEY BLOCK-----") {
+		block, err := armor.Decode(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding armored PGP key: %s", err)
+		}
+		return openpgp.ReadEntity(packet.NewReader(block.Body))
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("PGP key is neither armored nor base64 encoded: %s", err)
+	}
+	return openpgp.ReadEntity(packet.NewReader(bytes.NewReader(data)))
+}
+
+// resolvePGPKey parses pgp_key, looking up "keybase:<username>" references
+// on keybase.io first.
+func resolvePGPKey(key string, config Config) (*openpgp.Entity, error) {
+	if !strings.HasPrefix(key, "keybase:") {
+		return parsePGPKey(key)
+	}
+
+	username := strings.TrimPrefix(key, "keybase:")
+	lookupURL := keybaseLookupURL + "?fields=public_keys&usernames=" + url.QueryEscape(username)
+
+	req, err := http.NewRequest("GET", lookupURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating request: %s", lookupURL)
+	}
+	if config.ctx != nil {
+		req = req.WithContext(config.ctx)
+	}
+
+	client := config.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error looking up keybase user %s: %s", username, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading response body. %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error looking up keybase user %s. Response code: %d", username, resp.StatusCode)
+	}
+
+	var lookup struct {
+		Them []struct {
+			PublicKeys struct {
+				Primary struct {
+					Bundle string `json:"bundle"`
+				} `json:"primary"`
+			} `json:"public_keys"`
+		} `json:"them"`
+	}
+	if err := json.Unmarshal(body, &lookup); err != nil {
+		return nil, fmt.Errorf("Error while reading response body. %s", err)
+	}
+	if len(lookup.Them) == 0 || lookup.Them[0].PublicKeys.Primary.Bundle == "" {
+		return nil, fmt.Errorf("No public key found for keybase user %s", username)
+	}
+
+	return parsePGPKey(lookup.Them[0].PublicKeys.Primary.Bundle)
+}
+
+// encryptPGP encrypts plaintext to entity and returns the base64 encoded
+// binary message, ready for `base64 -d | gpg --decrypt`.
+func encryptPGP(entity *openpgp.Entity, plaintext string) (string, error) {
+	var buf bytes.Buffer
+	w, err := openpgp.Encrypt(&buf, []*openpgp.Entity{entity}, nil, nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("Error encrypting with PGP key: %s", err)
+	}
+	if _, err := w.Write([]byte(plaintext)); err != nil {
+		return "", fmt.Errorf("Error encrypting with PGP key: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("Error encrypting with PGP key: %s", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func pgpKeyFingerprint(entity *openpgp.Entity) string {
+	return hex.EncodeToString(entity.PrimaryKey.Fingerprint[:])
+}
+
+// privateKeyAttributes maps the attributes holding private key material to
+// the encrypted_* attributes used instead when pgp_key is set.
+func privateKeyAttributes() map[string]string {
+	attributes := map[string]string{"pem_private_certificate": "encrypted_private_key"}
+	for _, format := range certificateExportFormats {
+		if format.EncryptedAttribute != "" {
+			attributes[format.DataAttribute] = format.EncryptedAttribute
+		}
+	}
+	return attributes
+}
+
+// discardPrivateKeyData clears the plaintext private key material when
+// pgp_key is set. helper/schema saves the state even when an operation
+// fails, so this has to run on every error path which may follow fetching
+// the key, before sealPrivateKeyData got to encrypt it.
+func discardPrivateKeyData(d *schema.ResourceData) {
+	if d.Get("pgp_key").(string) == "" {
+		return
+	}
+	for attribute := range privateKeyAttributes() {
+		d.Set(attribute, "")
+	}
+}
+
+// sealPrivateKeyData moves the private key and the exports containing it
+// into their encrypted_* attributes when pgp_key is set, leaving no
+// plaintext behind. Attributes that are already empty are left alone, so the
+// ciphertext only changes when the key was fetched again.
+func sealPrivateKeyData(d *schema.ResourceData, config Config) error {
+	pgpKey := d.Get("pgp_key").(string)
+	attributes := privateKeyAttributes()
+
+	if pgpKey == "" {
+		for _, encryptedAttribute := range attributes {
+			d.Set(encryptedAttribute, "")
+		}
+		d.Set("pgp_key_fingerprint", "")
+		return nil
+	}
+
+	var entity *openpgp.Entity
+	for attribute, encryptedAttribute := range attributes {
+		plaintext := d.Get(attribute).(string)
+		if plaintext == "" {
+			continue
+		}
+
+		if entity == nil {
+			var err error
+			if entity, err = resolvePGPKey(pgpKey, config); err != nil {
+				return err
+			}
+			d.Set("pgp_key_fingerprint", pgpKeyFingerprint(entity))
+		}
+
+		ciphertext, err := encryptPGP(entity, plaintext)
+		if err != nil {
+			return err
+		}
+		d.Set(encryptedAttribute, ciphertext)
+		d.Set(attribute, "")
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package lemur
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func testPGPEntity(t *testing.T) (*openpgp.Entity, string) {
+	entity, err := openpgp.NewEntity("Terraform", "", "terraform@example.com", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// NewEntity leaves the self-signatures to the caller.
+	for _, identity := range entity.Identities {
+		if err := identity.SelfSignature.SignUserId(identity.UserId.Id, entity.PrimaryKey, entity.PrivateKey, nil); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	for _, subkey := range entity.Subkeys {
+		if err := subkey.Sig.SignKey(subkey.PublicKey, entity.PrivateKey, nil); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	w.Close()
+
+	return entity, buf.String()
+}
+
+func testPGPDecrypt(t *testing.T, entity *openpgp.Entity, ciphertext string) string {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	md, err := openpgp.ReadMessage(bytes.NewReader(data), openpgp.EntityList{entity}, nil, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	plaintext, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return string(plaintext)
+}
+
+func TestSealPrivateKeyData(t *testing.T) {
+	entity, armored := testPGPEntity(t)
+
+	if _, errs := validatePGPKey(armored, "pgp_key"); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if _, errs := validatePGPKey("not a key", "pgp_key"); len(errs) == 0 {
+		t.Fatal("expected error for an invalid key")
+	}
+
+	d := resourceLemurCertificate().TestResourceData()
+	d.SetId("1")
+	d.Set("pgp_key", armored)
+	d.Set("pem_private_certificate", "private key")
+	d.Set("pkcs_base_64", "pkcs12 data")
+	d.Set("der_base_64", "certificate")
+
+	if err := sealPrivateKeyData(d, Config{}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, attribute := range []string{"pem_private_certificate", "pkcs_base_64"} {
+		if v := d.Get(attribute).(string); v != "" {
+			t.Errorf("expected %s to be cleared, got %q", attribute, v)
+		}
+	}
+	if v := d.Get("der_base_64").(string); v != "certificate" {
+		t.Errorf("expected der_base_64 to be kept, got %q", v)
+	}
+
+	if v := testPGPDecrypt(t, entity, d.Get("encrypted_private_key").(string)); v != "private key" {
+		t.Errorf("unexpected encrypted_private_key plaintext: %q", v)
+	}
+	if v := testPGPDecrypt(t, entity, d.Get("encrypted_pkcs12").(string)); v != "pkcs12 data" {
+		t.Errorf("unexpected encrypted_pkcs12 plaintext: %q", v)
+	}
+	if v := d.Get("pgp_key_fingerprint").(string); v != pgpKeyFingerprint(entity) {
+		t.Errorf("unexpected pgp_key_fingerprint: %q", v)
+	}
+
+	// Sealing again without a freshly fetched key keeps the ciphertext.
+	encrypted := d.Get("encrypted_private_key").(string)
+	if err := sealPrivateKeyData(d, Config{}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if d.Get("encrypted_private_key").(string) != encrypted {
+		t.Error("expected encrypted_private_key to be kept")
+	}
+}
+
+func TestResourceLemurCertificate_pgpKeyErrors(t *testing.T) {
+	_, armored := testPGPEntity(t)
+
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.issued, lemur.issuedKey = testLemurCertificate(t, 1)
+
+	raw := testCertificateRawConfig()
+	raw["pgp_key"] = armored
+	raw["export_formats"] = []interface{}{"jks_keystore", "pkcs8_encrypted"}
+
+	resource := resourceLemurCertificate()
+	state, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if state.Attributes["encrypted_private_key"] == "" || state.Attributes["pem_private_certificate"] != "" {
+		t.Fatalf("expected only the encrypted key in state, got %v", state.Attributes)
+	}
+
+	assertNoPlaintext := func(state *terraform.InstanceState) {
+		for attribute := range privateKeyAttributes() {
+			if v := state.Attributes[attribute]; v != "" {
+				t.Errorf("expected no plaintext in %s after a failed operation, got %q", attribute, v)
+			}
+		}
+	}
+
+	// The key is fetched again for the changed exports, and the failing
+	// export must not leave it behind.
+	lemur.exportErrors["java-keystore-jks"] = "Keytool failed"
+	raw["jks_alias"] = "web"
+	updated, err := resource.Apply(state, testResourceDiff(t, resource, state, raw), lemur.config())
+	if err == nil {
+		t.Fatal("expected the failing export to fail the update")
+	}
+	assertNoPlaintext(updated)
+
+	// The same goes for a read of a rotated certificate.
+	delete(state.Attributes, "certificate_id")
+	refreshed, err := resource.Refresh(state, lemur.config())
+	if err == nil {
+		t.Fatal("expected the failing export to fail the read")
+	}
+	assertNoPlaintext(refreshed)
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
@@ -34,15 +36,33 @@ func Provider() terraform.ResourceProvider {
 				DefaultFunc: schema.EnvDefaultFunc("LEMUR_PASSWORD", ""),
 				Description: "The password to authenticate with",
 			},
+
+			"request_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("LEMUR_REQUEST_TIMEOUT", "60s"),
+				Description:  "The maximum duration of a single request to the Lemur server",
+				ValidateFunc: validateDuration,
+			},
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"lemur_certificate": resourceLemurCertificate(),
+			"lemur_certificate":                         resourceLemurCertificate(),
+			"lemur_certificate_destination_attachment":  resourceLemurCertificateDestinationAttachment(),
+			"lemur_certificate_notification_attachment": resourceLemurCertificateNotificationAttachment(),
+			"lemur_certificate_revocation":              resourceLemurCertificateRevocation(),
+			"lemur_certificate_role_attachment":         resourceLemurCertificateRoleAttachment(),
+			"lemur_dns_provider":                        resourceLemurDNSProvider(),
+			"lemur_rotation_policy":                     resourceLemurRotationPolicy(),
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"lemur_certificate": dataSourceLemurCertificate(),
-			"lemur_authority":   dataSourceLemurAuthority(),
+			"lemur_certificate":     dataSourceLemurCertificate(),
+			"lemur_certificates":    dataSourceLemurCertificates(),
+			"lemur_authority":       dataSourceLemurAuthority(),
+			"lemur_authorities":     dataSourceLemurAuthorities(),
+			"lemur_authority_chain": dataSourceLemurAuthorityChain(),
+			"lemur_trust_bundle":    dataSourceLemurTrustBundle(),
 		},
 
 		ConfigureFunc: providerConfigure,
@@ -54,7 +74,14 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 	username := d.Get("username").(string)
 	password := d.Get("password").(string)
 
-	client := &http.Client{}
+	requestTimeout, err := time.ParseDuration(d.Get("request_timeout").(string))
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing request_timeout: %s", err)
+	}
+
+	client := &http.Client{
+		Timeout: requestTimeout,
+	}
 
 	authURL := host + "/api/1/auth/login"
 	loginData := map[string]string{"username": username, "password": password}
@@ -69,6 +96,9 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 
 	resp, err := client.Do(req)
 	if err != nil {
+		if err, ok := err.(net.Error); ok && err.Timeout() {
+			return nil, fmt.Errorf("Request timed out: %s", authURL)
+		}
 		return nil, fmt.Errorf("Error during making a request: %s", authURL)
 	}
 
@@ -92,8 +122,9 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 	token := jsonReponse["token"].(string)
 
 	config := Config{
-		Host:  host,
-		Token: token,
+		Host:   host,
+		Token:  token,
+		Client: client,
 	}
 
 	return config, nil
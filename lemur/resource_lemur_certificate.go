@@ -1,13 +1,13 @@
 package lemur
 
 import (
-	"bytes"
-	"encoding/json"
+	"crypto/x509"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"log"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform/helper/schema"
 )
@@ -20,6 +20,15 @@ func resourceLemurCertificate() *schema.Resource {
 		Update: resourceLemurCertificateUpdate,
 		Delete: resourceLemurCertificateDelete,
 
+		CustomizeDiff: resourceLemurCertificateCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:     schema.TypeString,
@@ -65,18 +74,40 @@ func resourceLemurCertificate() *schema.Resource {
 				Type:     schema.TypeInt,
 				Optional: true,
 			},
+			"rotation": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"rotation_policy": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			// The DNS provider is only used for the ACME challenge at issuance.
+			"dns_provider": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
 			"san": {
 				Type:     schema.TypeSet,
 				Optional: true,
+				Computed: true,
+				ForceNew: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"type": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validateStringInSlice(sanNameTypes),
 						},
 						"value": {
 							Type:     schema.TypeString,
 							Required: true,
+							ForceNew: true,
 						},
 					},
 				},
@@ -85,20 +116,184 @@ func resourceLemurCertificate() *schema.Resource {
 			"extended_key_usage": {
 				Type:     schema.TypeSet,
 				Optional: true,
+				Computed: true,
+				ForceNew: true,
 				MaxItems: 1,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"use_client_authentication": {
 							Type:     schema.TypeBool,
-							Required: true,
+							Optional: true,
+							ForceNew: true,
 						},
 						"use_server_authentication": {
 							Type:     schema.TypeBool,
-							Required: true,
+							Optional: true,
+							ForceNew: true,
+						},
+						"use_code_signing": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							ForceNew: true,
+						},
+						"use_email_protection": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							ForceNew: true,
+						},
+						"use_time_stamping": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							ForceNew: true,
+						},
+						"use_ocsp_signing": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							ForceNew: true,
+						},
+						"oids": {
+							Type:     schema.TypeSet,
+							Optional: true,
+							ForceNew: true,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validateOID,
+							},
+							Set: schema.HashString,
+						},
+					},
+				},
+				Set: resourceEKUHash,
+			},
+			"key_usage": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				ForceNew: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateStringInSlice(keyUsageNames()),
+				},
+				Set: schema.HashString,
+			},
+			"basic_constraints": {
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ca": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							ForceNew: true,
+						},
+						// -1 leaves the path length unconstrained.
+						"path_length": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ForceNew:     true,
+							Default:      -1,
+							ValidateFunc: validateIntAtLeast(-1),
+						},
+					},
+				},
+			},
+			"authority_info_access": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  false,
+			},
+			"crl_distribution_points": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  false,
+			},
+			"custom_extension": {
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"oid": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validateOID,
+						},
+						"critical": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							ForceNew: true,
+							Default:  false,
+						},
+						// The DER encoded extension value, base64 encoded.
+						"value": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validateBase64,
 						},
 					},
 				},
-				Set: resourceSANHash,
+			},
+
+			"export_formats": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateStringInSlice(certificateExportFormatNames()),
+				},
+				Set: schema.HashString,
+			},
+			"invalid_certificate_action": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "replace",
+				ValidateFunc: validateStringInSlice([]string{"replace", "remove"}),
+			},
+			"replacement_reason": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			// Move to the certificate Lemur issued as a replacement, e.g. by
+			// rotation, instead of matching the certificate by name.
+			"follow_rotation": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			// The ID of the certificate follow_rotation moved away from, which
+			// shows up as an in-place update until it is applied.
+			"rotated_from": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			// Refuse to destroy, or replace, the certificate while Lemur
+			// still reports endpoints serving it.
+			"prevent_destroy_if_in_use": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			// Where the private key may go: "state" stores it like any other
+			// attribute, "omit" never fetches it, and "destination_only"
+			// also never fetches it but expects a Lemur destination to
+			// deliver it.
+			"private_key_handling": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "state",
+				ValidateFunc: validateStringInSlice(privateKeyHandlingModes),
+			},
+			// Fail instead of warning when the chain or private key returned
+			// by Lemur does not belong to the certificate.
+			"strict_verification": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
 			},
 
 			"pem_chain": &schema.Schema{
@@ -119,7 +314,42 @@ func resourceLemurCertificate() *schema.Resource {
 				Computed:  true,
 				Sensitive: true,
 			},
+			// Encrypts the private key and the exports containing it, which
+			// are then only stored in the encrypted_* attributes. Either an
+			// armored or base64 encoded public key, or "keybase:<username>".
+			"pgp_key": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validatePGPKey,
+			},
+			"pgp_key_fingerprint": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"encrypted_private_key": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"encrypted_pkcs12": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"encrypted_jks_keystore": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"encrypted_pkcs8_private_key": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"pkcs_passphrase": &schema.Schema{
+				Type:       schema.TypeString,
+				Optional:   true,
+				Computed:   true,
+				Sensitive:  true,
+				Deprecated: "Use pkcs12_passphrase instead",
+			},
+			"pkcs12_passphrase": &schema.Schema{
 				Type:      schema.TypeString,
 				Optional:  true,
 				Computed:  true,
@@ -155,27 +385,158 @@ func resourceLemurCertificate() *schema.Resource {
 				Computed:  true,
 				Sensitive: true,
 			},
+			"jks_alias": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"pkcs7_base_64": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"der_base_64": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"pem_full_chain": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"pkcs8_passphrase": &schema.Schema{
+				Type:      schema.TypeString,
+				Optional:  true,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"pkcs8_encrypted_private_key": &schema.Schema{
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			// The data of a kubernetes.io/tls secret: tls.crt holds the
+			// certificate followed by its chain, ca.crt the top of the chain
+			// if there is one.
+			"kubernetes_tls_data": &schema.Schema{
+				Type:      schema.TypeMap,
+				Computed:  true,
+				Sensitive: true,
+			},
+			// Encoding of tls.key. "pkcs1" is the traditional RSA or EC
+			// structure, which some ingress controllers require.
+			"kubernetes_key_format": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "pkcs1",
+				ValidateFunc: validateStringInSlice([]string{"pkcs1", "pkcs8"}),
+			},
 			"certificate_id": &schema.Schema{
 				Type:     schema.TypeInt,
 				Optional: true,
 				Computed: true,
 			},
+
+			"serial": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"not_before": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"not_after": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"issuer": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"bits": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"key_type": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"san_names": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"sha1_fingerprint": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"sha256_fingerprint": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"lemur_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"replaces": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"replaced_by": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"endpoints": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"dnsname": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"port": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"chain_valid": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"key_matches": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
 		},
 	}
 }
 
 func resourceLemurCertificateCreate(d *schema.ResourceData, meta interface{}) error {
-	exists, err := resourceLemurCertificateExists(d, meta)
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	exists, err := resourceLemurCertificateExists(d, config)
 	if err != nil {
 		return err
 	}
 	if exists {
-		return resourceLemurCertificateRead(d, meta)
+		return resourceLemurCertificateRead(d, config)
 	}
 
-	client := &http.Client{}
-	config := meta.(Config)
-
 	url := config.Host + "/api/1/certificates"
 	requestData := CreateCertificateRequest{
 		Authority: CreateCertificateRequestAuthority{
@@ -185,7 +546,7 @@ func resourceLemurCertificateCreate(d *schema.ResourceData, meta interface{}) er
 		Owner:         d.Get("owner").(string),
 		CommonName:    d.Get("common_name").(string),
 		Description:   d.Get("description").(string),
-		Rotation:      true,
+		Rotation:      d.Get("rotation").(bool),
 		Notify:        true,
 		ValidityYears: d.Get("validity_years").(int),
 	}
@@ -211,6 +572,22 @@ func resourceLemurCertificateCreate(d *schema.ResourceData, meta interface{}) er
 		requestData.Country = val.(string)
 	}
 
+	if v, ok := d.GetOk("rotation_policy"); ok {
+		requestData.RotationPolicy = &CreateCertificateRotationPolicy{
+			Name: v.(string),
+		}
+	}
+
+	if v, ok := d.GetOk("dns_provider"); ok {
+		dnsProviderID, err := strconv.Atoi(v.(string))
+		if err != nil {
+			return fmt.Errorf("Invalid DNS provider ID: %s", v.(string))
+		}
+		requestData.DNSProvider = &CreateCertificateDNSProvider{
+			ID: dnsProviderID,
+		}
+	}
+
 	requestData.Extensions = CreateCertificateExtensions{}
 
 	if sans := d.Get("san").(*schema.Set); sans.Len() > 0 {
@@ -220,6 +597,10 @@ func resourceLemurCertificateCreate(d *schema.ResourceData, meta interface{}) er
 		for _, san := range sans.List() {
 			san := san.(map[string]interface{})
 
+			if err := validateSAN(san["type"].(string), san["value"].(string)); err != nil {
+				return err
+			}
+
 			sanValue := CreateCertificateNames{
 				NameType: san["type"].(string),
 				Value:    san["value"].(string),
@@ -232,122 +613,449 @@ func resourceLemurCertificateCreate(d *schema.ResourceData, meta interface{}) er
 	if keyUsages, ok := d.GetOk("extended_key_usage"); ok {
 		keyUsages := keyUsages.(*schema.Set).List()
 		keyUsage := keyUsages[0].(map[string]interface{})
-		requestData.Extensions.ExtendedKeyUsage = CreateCertificateExtendedKeyUsage{
-			UseClientAuthentication: keyUsage["use_client_authentication"].(bool),
-			UseServerAuthentication: keyUsage["use_server_authentication"].(bool),
+
+		// Lemur only knows the common usages, so arbitrary OIDs are sent
+		// as a complete extension of their own.
+		if keyUsage["oids"].(*schema.Set).Len() > 0 {
+			value, err := encodeExtendedKeyUsage(keyUsage)
+			if err != nil {
+				return err
+			}
+			requestData.Extensions.Custom = append(requestData.Extensions.Custom, CreateCertificateCustomExtension{
+				OID:      oidExtensionExtendedKeyUsage.String(),
+				Encoding: "b64asn1",
+				Value:    value,
+			})
+		} else {
+			requestData.Extensions.ExtendedKeyUsage = &CreateCertificateExtendedKeyUsage{
+				UseClientAuthentication: keyUsage["use_client_authentication"].(bool),
+				UseServerAuthentication: keyUsage["use_server_authentication"].(bool),
+				UseCodeSigning:          keyUsage["use_code_signing"].(bool),
+				UseEmailProtection:      keyUsage["use_email_protection"].(bool),
+				UseTimestamping:         keyUsage["use_time_stamping"].(bool),
+				UseOCSPSigning:          keyUsage["use_ocsp_signing"].(bool),
+			}
 		}
 	}
 
-	jsonValue, _ := json.Marshal(requestData)
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonValue))
-	if err != nil {
-		return fmt.Errorf("Error creating request: %s", url)
+	if keyUsages := d.Get("key_usage").(*schema.Set); keyUsages.Len() > 0 {
+		requestData.Extensions.KeyUsage = expandKeyUsage(keyUsages)
 	}
-	req.Header.Set("Authorization", "bearer "+config.Token)
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("Error during making a request: %s", url)
+	if v, ok := d.GetOk("basic_constraints"); ok {
+		constraints := v.([]interface{})[0].(map[string]interface{})
+		requestData.Extensions.BasicConstraints = &CreateCertificateBasicConstraints{
+			CA: constraints["ca"].(bool),
+		}
+		if pathLength := constraints["path_length"].(int); pathLength >= 0 {
+			requestData.Extensions.BasicConstraints.PathLength = &pathLength
+		}
 	}
 
-	defer resp.Body.Close()
+	if d.Get("authority_info_access").(bool) {
+		requestData.Extensions.CertificateInfoAccess = &CreateCertificateInfoAccess{
+			IncludeAIA: true,
+		}
+	}
 
-	responseBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("Error while reading response body. %s", err)
+	if d.Get("crl_distribution_points").(bool) {
+		requestData.Extensions.CRLDistributionPoints = &CreateCertificateCRLDistributionPoints{
+			IncludeCRLDP: true,
+		}
 	}
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("HTTP request error. Response code: %d \n%s", resp.StatusCode, string(responseBytes[:]))
+	for _, v := range d.Get("custom_extension").([]interface{}) {
+		extension := v.(map[string]interface{})
+		requestData.Extensions.Custom = append(requestData.Extensions.Custom, CreateCertificateCustomExtension{
+			OID:        extension["oid"].(string),
+			Encoding:   "b64asn1",
+			Value:      extension["value"].(string),
+			IsCritical: extension["critical"].(bool),
+		})
 	}
 
-	var keyReponse map[string]interface{}
-	err = json.Unmarshal(responseBytes, &keyReponse)
+	err = config.request("POST", url, requestData, nil)
 	if err != nil {
-		return fmt.Errorf("Error while reading response body. %s", err)
+		return err
+	}
+
+	return resourceLemurCertificateRead(d, config)
+}
+
+// keyUsageNames lists the accepted key_usage values.
+func keyUsageNames() []string {
+	return []string{
+		"digital_signature",
+		"non_repudiation",
+		"key_encipherment",
+		"data_encipherment",
+		"key_agreement",
+		"cert_sign",
+		"crl_sign",
+		"encipher_only",
+		"decipher_only",
 	}
+}
 
-	return resourceLemurCertificateRead(d, meta)
+func expandKeyUsage(keyUsages *schema.Set) *CreateCertificateKeyUsage {
+	return &CreateCertificateKeyUsage{
+		UseDigitalSignature: keyUsages.Contains("digital_signature"),
+		UseNonRepudiation:   keyUsages.Contains("non_repudiation"),
+		UseKeyEncipherment:  keyUsages.Contains("key_encipherment"),
+		UseDataEncipherment: keyUsages.Contains("data_encipherment"),
+		UseKeyAgreement:     keyUsages.Contains("key_agreement"),
+		UseKeyCertSign:      keyUsages.Contains("cert_sign"),
+		UseCRLSign:          keyUsages.Contains("crl_sign"),
+		UseEncipherOnly:     keyUsages.Contains("encipher_only"),
+		UseDecipherOnly:     keyUsages.Contains("decipher_only"),
+	}
 }
 
-func resourceLemurCertificateUpdate(d *schema.ResourceData, meta interface{}) error {
-	exists, err := resourceLemurCertificateExists(d, meta)
+func resourceLemurCertificateUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+	defer func() {
+		if err != nil {
+			discardPrivateKeyData(d)
+		}
+	}()
+
+	exists, err := resourceLemurCertificateExists(d, config)
 	if err != nil {
 		return err
 	}
-	if exists {
+	if !exists {
 		return nil
 	}
 
-	return nil
-	//return fmt.Errorf("Update is not supported: %s", d.Id())
+	if d.HasChange("rotation") || d.HasChange("rotation_policy") {
+		certificateID, err := strconv.Atoi(d.Id())
+		if err != nil {
+			return fmt.Errorf("Invalid certificate ID: %s", d.Id())
+		}
+
+		_, err = updateCertificate(certificateID, config, func(requestData map[string]interface{}) error {
+			requestData["rotation"] = d.Get("rotation").(bool)
+			if v, ok := d.GetOk("rotation_policy"); ok {
+				requestData["rotationPolicy"] = map[string]interface{}{
+					"name": v.(string),
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	changed := changedCertificateExports(d)
+	keyChanged := d.HasChange("private_key_handling") || d.HasChange("pgp_key")
+	needKey := keyChanged
+	for name, format := range certificateExportFormats {
+		if !format.PrivateKey || !d.Get("export_formats").(*schema.Set).Contains(name) {
+			continue
+		}
+		// The exports holding the key were skipped, or encrypted to another
+		// PGP key, until now.
+		if keyChanged {
+			changed.Add(name)
+		}
+		// With pgp_key the plaintext key is not in state, so it has to be
+		// fetched again for the local exports.
+		if changed.Contains(name) && d.Get("pgp_key").(string) != "" {
+			needKey = true
+		}
+	}
+
+	if needKey {
+		certificateID, err := strconv.Atoi(d.Id())
+		if err != nil {
+			return fmt.Errorf("Invalid certificate ID: %s", d.Id())
+		}
+
+		privateCert := ""
+		if privateKeyInState(d) {
+			privateCert, err = getPrivateCertificateData(certificateID, d, config)
+			if err != nil {
+				return err
+			}
+		}
+		d.Set("pem_private_certificate", privateCert)
+	}
+
+	if changed.Len() > 0 || d.HasChange("export_formats") || keyChanged {
+		certificateID, err := strconv.Atoi(d.Id())
+		if err != nil {
+			return fmt.Errorf("Invalid certificate ID: %s", d.Id())
+		}
+
+		if err := exportCertificateFormats(certificateID, changed, d, config); err != nil {
+			return err
+		}
+		clearCertificateExports(d.Get("export_formats").(*schema.Set), d)
+		d.Set("pkcs_passphrase", d.Get("pkcs12_passphrase"))
+	}
+
+	return resourceLemurCertificateRead(d, config)
 }
 
 func resourceLemurCertificateExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	client := &http.Client{}
-	config := meta.(Config)
-	name := d.Get("name").(string)
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutRead))
+	defer cancel()
 
-	findExistingURL := config.Host + "/api/1/certificates?filter=name;" + name
-	findExisting, err := http.NewRequest("GET", findExistingURL, nil)
+	certificate, err := getManagedCertificate(d, config)
 	if err != nil {
-		return false, fmt.Errorf("Error creating request: %s", findExistingURL)
+		return false, err
+	}
+	if certificate != nil {
+		return true, nil
+	}
+
+	// Keep revoked, expired or deactivated certificates around so that Read
+	// can plan their replacement.
+	if d.Id() == "" || d.Get("invalid_certificate_action").(string) == "remove" {
+		return false, nil
 	}
-	findExisting.Header.Set("Authorization", "bearer "+config.Token)
 
-	findExistingResponse, err := client.Do(findExisting)
+	reason, err := trackedCertificateInvalidReason(d, config)
 	if err != nil {
-		return false, fmt.Errorf("Error during making a request: %s", findExistingURL)
+		return false, err
 	}
 
-	defer findExistingResponse.Body.Close()
+	return reason != "", nil
+}
+
+// maxRotations bounds how many replacements follow_rotation walks through.
+const maxRotations = 10
 
-	if findExistingResponse.StatusCode != 200 {
-		return false, fmt.Errorf("HTTP request error. Response code: %d", findExistingResponse.StatusCode)
+// getManagedCertificate finds the certificate this resource manages. With
+// follow_rotation the certificate in state is looked up by ID and any
+// replacements Lemur recorded are followed, otherwise it is matched by name.
+// Returns nil when the certificate in state is gone or no longer valid and
+// has no valid replacement.
+func getManagedCertificate(d *schema.ResourceData, config Config) (map[string]interface{}, error) {
+	if !d.Get("follow_rotation").(bool) || d.Id() == "" {
+		return getCertificate(d, config)
 	}
 
-	responseBytes, err := ioutil.ReadAll(findExistingResponse.Body)
+	certificateID, err := strconv.Atoi(d.Id())
 	if err != nil {
-		return false, fmt.Errorf("Error while reading response body. %s", err)
+		return nil, fmt.Errorf("Invalid certificate ID: %s", d.Id())
 	}
 
-	var certificatesResponse map[string]interface{}
-	err = json.Unmarshal(responseBytes, &certificatesResponse)
-	if err != nil {
-		return false, fmt.Errorf("Error while reading response body. %s", err)
+	certificate, err := getCertificateByID(certificateID, config)
+	if err != nil || certificate == nil {
+		return nil, err
+	}
+
+	for i := 0; i < maxRotations; i++ {
+		replacement, err := getValidReplacement(certificate, config)
+		if err != nil {
+			return nil, err
+		}
+		if replacement == nil {
+			break
+		}
+
+		log.Printf("[INFO] Certificate %v was replaced by %v in Lemur, following the rotation", certificate["id"], replacement["id"])
+		certificate = replacement
 	}
 
-	totalResults := certificatesResponse["total"].(float64)
-	if totalResults > 0 {
-		items := certificatesResponse["items"].([]interface{})
-		for _, item := range items {
-			itemMap := item.(map[string]interface{})
-			if itemMap["active"].(bool) && strings.HasPrefix(name, itemMap["name"].(string)) {
-				return true, nil
+	if certificateInvalidReason(certificate) != "" {
+		return nil, nil
+	}
+
+	return certificate, nil
+}
+
+// getValidReplacement returns the most recent replacement of certificate
+// which is still valid. Invalid replacements, e.g. one revoked right after
+// the rotation, are skipped so the resource never moves to them.
+func getValidReplacement(certificate map[string]interface{}, config Config) (map[string]interface{}, error) {
+	replacements := certificateLinks(certificate, "replacedBy")
+	for i := len(replacements) - 1; i >= 0; i-- {
+		replacementID, _ := strconv.Atoi(replacements[i])
+		replacement, err := getCertificateByID(replacementID, config)
+		if err != nil {
+			return nil, err
+		}
+		if replacement == nil {
+			continue
+		}
+		if reason := certificateInvalidReason(replacement); reason != "" {
+			log.Printf("[WARN] Certificate %v was replaced by %d in Lemur, which is %s, not following the rotation", certificate["id"], replacementID, reason)
+			continue
+		}
+		return replacement, nil
+	}
+
+	return nil, nil
+}
+
+// certificateLinks returns the IDs of the certificates listed under key,
+// "replaces" or "replacedBy", in ascending order.
+func certificateLinks(certificate map[string]interface{}, key string) []string {
+	ids := []int{}
+	items, _ := certificate[key].([]interface{})
+	for _, item := range items {
+		if item, ok := item.(map[string]interface{}); ok {
+			if id, ok := item["id"].(float64); ok {
+				ids = append(ids, int(id))
 			}
 		}
 	}
+	sort.Ints(ids)
 
-	return false, nil
+	links := make([]string, 0, len(ids))
+	for _, id := range ids {
+		links = append(links, strconv.Itoa(id))
+	}
+	return links
+}
+
+// trackedCertificateInvalidReason explains why the certificate in state is no
+// longer matched by name.
+func trackedCertificateInvalidReason(d *schema.ResourceData, config Config) (string, error) {
+	if d.Id() == "" {
+		return "", nil
+	}
+
+	certificateID, err := strconv.Atoi(d.Id())
+	if err != nil {
+		return "", fmt.Errorf("Invalid certificate ID: %s", d.Id())
+	}
+
+	certificate, err := getCertificateByID(certificateID, config)
+	if err != nil {
+		return "", err
+	}
+	if certificate == nil {
+		return "deleted", nil
+	}
+
+	if reason := certificateInvalidReason(certificate); reason != "" {
+		return reason, nil
+	}
+
+	return "renamed", nil
+}
+
+// handleInvalidCertificate either drops an unusable certificate from state or
+// records why it has to be replaced, which forces a new resource in the plan.
+func handleInvalidCertificate(d *schema.ResourceData, reason string) error {
+	if reason == "" || d.Get("invalid_certificate_action").(string) == "remove" {
+		log.Printf("[WARN] Certificate %s is %s in Lemur, removing it from state", d.Id(), reason)
+		d.SetId("")
+		return nil
+	}
+
+	log.Printf("[WARN] Certificate %s is %s in Lemur, planning a replacement", d.Id(), reason)
+	d.Set("replacement_reason", fmt.Sprintf("certificate %s is %s", d.Id(), reason))
+
+	return nil
+}
+
+// resourceLemurCertificateCustomizeDiff rejects export formats that conflict
+// with private_key_handling, plans a new certificate when Read recorded a
+// replacement_reason for the one in state, and clears rotated_from once a
+// rotation has been followed.
+func resourceLemurCertificateCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if err := validatePrivateKeyHandling(d); err != nil {
+		return err
+	}
+
+	if d.Get("rotated_from").(string) != "" {
+		if err := d.SetNew("rotated_from", ""); err != nil {
+			return err
+		}
+	}
+
+	if d.Get("replacement_reason").(string) == "" {
+		return nil
+	}
+
+	if err := d.SetNew("replacement_reason", ""); err != nil {
+		return err
+	}
+	return d.ForceNew("replacement_reason")
 }
 
 func resourceLemurCertificateDelete(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutDelete))
+	defer cancel()
+
+	if d.Get("prevent_destroy_if_in_use").(bool) {
+		certificateID, err := strconv.Atoi(d.Id())
+		if err != nil {
+			return fmt.Errorf("Invalid certificate ID: %s", d.Id())
+		}
+
+		certificate, err := getCertificateByID(certificateID, config)
+		if err != nil {
+			return err
+		}
+		if certificate != nil {
+			if endpoints := flattenCertificateEndpoints(certificate); len(endpoints) > 0 {
+				names := make([]string, 0, len(endpoints))
+				for _, endpoint := range endpoints {
+					names = append(names, fmt.Sprintf("%s (%s, %s:%d)",
+						endpoint["name"], endpoint["type"], endpoint["dnsname"], endpoint["port"]))
+				}
+				return fmt.Errorf("Certificate %s is still used by %d endpoints: %s",
+					d.Id(), len(endpoints), strings.Join(names, ", "))
+			}
+		}
+	}
+
 	//TODO
 	return nil
 }
 
-func resourceLemurCertificateRead(d *schema.ResourceData, meta interface{}) error {
-	config := meta.(Config)
+// flattenCertificateEndpoints lists the endpoints Lemur knows to serve a
+// certificate.
+func flattenCertificateEndpoints(certificate map[string]interface{}) []map[string]interface{} {
+	endpoints := []map[string]interface{}{}
+	items, _ := certificate["endpoints"].([]interface{})
+	for _, item := range items {
+		endpoint, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		port := 0
+		if v, ok := endpoint["port"].(float64); ok {
+			port = int(v)
+		}
+
+		endpoints = append(endpoints, map[string]interface{}{
+			"name":    stringValue(endpoint, "name"),
+			"type":    stringValue(endpoint, "type"),
+			"dnsname": stringValue(endpoint, "dnsname"),
+			"port":    port,
+		})
+	}
+	return endpoints
+}
+
+func resourceLemurCertificateRead(d *schema.ResourceData, meta interface{}) (err error) {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutRead))
+	defer cancel()
+	defer func() {
+		if err != nil {
+			discardPrivateKeyData(d)
+		}
+	}()
 
-	certificate, err := getCertificate(d, config)
+	certificate, err := getManagedCertificate(d, config)
 	if err != nil {
 		return err
 	}
 	if certificate == nil {
-		d.SetId("")
-		return nil
+		reason, err := trackedCertificateInvalidReason(d, config)
+		if err != nil {
+			return err
+		}
+		return handleInvalidCertificate(d, reason)
 	}
 
 	currentCertificateID := 0
@@ -362,9 +1070,40 @@ func resourceLemurCertificateRead(d *schema.ResourceData, meta interface{}) erro
 	d.Set("common_name", certificate["commonName"].(string))
 	d.Set("owner", certificate["owner"].(string))
 
+	if rotation, ok := certificate["rotation"].(bool); ok {
+		d.Set("rotation", rotation)
+	}
+	if rotationPolicy, ok := certificate["rotationPolicy"].(map[string]interface{}); ok {
+		d.Set("rotation_policy", stringValue(rotationPolicy, "name"))
+	}
+
+	if dnsProvider, ok := certificate["dnsProvider"].(map[string]interface{}); ok {
+		if id, ok := dnsProvider["id"].(float64); ok {
+			d.Set("dns_provider", strconv.Itoa(int(id)))
+		}
+	}
+
+	if d.Get("private_key_handling").(string) == "destination_only" {
+		if destinations, _ := certificate["destinations"].([]interface{}); len(destinations) == 0 {
+			log.Printf("[WARN] Certificate %v has no destinations, so its private key is not delivered anywhere", certificate["id"])
+		}
+	}
+
+	if err := d.Set("endpoints", flattenCertificateEndpoints(certificate)); err != nil {
+		return err
+	}
+	d.Set("replaces", certificateLinks(certificate, "replaces"))
+	d.Set("replaced_by", certificateLinks(certificate, "replacedBy"))
+
 	certificateID := int(certificate["id"].(float64))
+	if previousID := d.Id(); d.Get("follow_rotation").(bool) && previousID != "" && previousID != strconv.Itoa(certificateID) {
+		// Moving to the replacement happens during refresh, so the previous
+		// ID is recorded for the plan to show it.
+		d.Set("rotated_from", previousID)
+	}
 	d.Set("certificate_id", certificateID)
 	d.SetId(strconv.Itoa(certificateID))
+	d.Set("replacement_reason", "")
 
 	if certificateID != currentCertificateID {
 
@@ -373,30 +1112,99 @@ func resourceLemurCertificateRead(d *schema.ResourceData, meta interface{}) erro
 			return err
 		}
 
-		privateCert, err := getPrivateCertificateData(certificateID, d, config)
-		if err != nil {
+		privateCert := ""
+		if privateKeyInState(d) {
+			privateCert, err = getPrivateCertificateData(certificateID, d, config)
+			if err != nil {
+				return err
+			}
+		}
+
+		d.Set("pem_chain", chain)
+		d.Set("pem_public_certificate", publicCert)
+		d.Set("pem_private_certificate", privateCert)
+
+		formats := d.Get("export_formats").(*schema.Set)
+		if err := exportCertificateFormats(certificateID, formats, d, config); err != nil {
 			return err
 		}
+		clearCertificateExports(formats, d)
+		d.Set("pkcs_passphrase", d.Get("pkcs12_passphrase"))
+	}
 
-		pkcsBase64, pkcsPassphrase, err := exportCertificatePKCS(certificateID, d, config)
+	if err := setCertificateMetadata(d, certificate, d.Get("pem_public_certificate").(string)); err != nil {
+		return err
+	}
 
-		jksKeystoreBase64, jksKeystorePassphrase, err := exportCertificateJKSKeystore(certificateID, d, config)
+	if err := setCertificateVerification(d); err != nil {
+		return err
+	}
 
-		jksTruststoreBase64, jksTruststorePassphrase, err := exportCertificateJKSTruststore(certificateID, d, config)
+	if err := setKubernetesTLSData(d); err != nil {
+		return err
+	}
 
-		d.Set("pem_chain", chain)
-		d.Set("pem_public_certificate", publicCert)
-		d.Set("pem_private_certificate", privateCert)
+	if err := sealPrivateKeyData(d, config); err != nil {
+		return err
+	}
 
-		d.Set("pkcs_passphrase", pkcsPassphrase)
-		d.Set("pkcs_base_64", pkcsBase64)
+	return setCertificateExtensions(d, d.Get("pem_public_certificate").(string))
+}
 
-		d.Set("jks_keystore_passphrase", jksKeystorePassphrase)
-		d.Set("jks_keystore_base_64", jksKeystoreBase64)
+// setCertificateExtensions reads the extensions back from the issued
+// certificate so that changes made outside of Terraform show up in the plan.
+func setCertificateExtensions(d *schema.ResourceData, publicCert string) error {
+	if publicCert == "" {
+		return nil
+	}
 
-		d.Set("jks_truststore_passphrase", jksTruststorePassphrase)
-		d.Set("jks_truststore_base_64", jksTruststoreBase64)
+	cert, err := parsePEMCertificate(publicCert)
+	if err != nil {
+		return fmt.Errorf("Error parsing certificate %s: %s", d.Id(), err)
 	}
 
-	return nil
+	if err := d.Set("san", flattenSubjectAltNames(cert, d.Get("common_name").(string), d.Get("san").(*schema.Set))); err != nil {
+		return err
+	}
+
+	return d.Set("extended_key_usage", flattenExtendedKeyUsage(cert, d.Get("extended_key_usage").(*schema.Set)))
+}
+
+// flattenSubjectAltNames describes the SANs of a certificate in the shape of
+// the san block. Lemur adds the common name as a DNSName on its own, so it is
+// only kept when it was already known. Directory names are not parsed by
+// crypto/x509 and are carried over from the previous state.
+func flattenSubjectAltNames(cert *x509.Certificate, commonName string, previous *schema.Set) []interface{} {
+	sans := []interface{}{}
+	add := func(nameType string, value string) {
+		san := map[string]interface{}{
+			"type":  nameType,
+			"value": value,
+		}
+		if nameType == "DNSName" && value == commonName && !previous.Contains(san) {
+			return
+		}
+		sans = append(sans, san)
+	}
+
+	for _, name := range cert.DNSNames {
+		add("DNSName", name)
+	}
+	for _, ip := range cert.IPAddresses {
+		add("IPAddress", ip.String())
+	}
+	for _, uri := range cert.URIs {
+		add("uniformResourceIdentifier", uri.String())
+	}
+	for _, email := range cert.EmailAddresses {
+		add("rfc822Name", email)
+	}
+
+	for _, v := range previous.List() {
+		if san := v.(map[string]interface{}); san["type"].(string) == "directoryName" {
+			sans = append(sans, san)
+		}
+	}
+
+	return sans
 }
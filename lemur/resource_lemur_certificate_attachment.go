@@ -0,0 +1,159 @@
+package lemur
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// certificateAttachment describes one kind of object that can be linked to a
+// certificate: the certificate field holding the links and the attribute
+// naming the linked object.
+type certificateAttachment struct {
+	Field       string
+	IDAttribute string
+}
+
+func resourceLemurCertificateNotificationAttachment() *schema.Resource {
+	return resourceLemurCertificateAttachment(certificateAttachment{Field: "notifications", IDAttribute: "notification_id"})
+}
+
+func resourceLemurCertificateDestinationAttachment() *schema.Resource {
+	return resourceLemurCertificateAttachment(certificateAttachment{Field: "destinations", IDAttribute: "destination_id"})
+}
+
+func resourceLemurCertificateRoleAttachment() *schema.Resource {
+	return resourceLemurCertificateAttachment(certificateAttachment{Field: "roles", IDAttribute: "role_id"})
+}
+
+// resourceLemurCertificateAttachment manages a single link of a certificate,
+// which may be managed outside of Terraform, leaving its other links alone.
+func resourceLemurCertificateAttachment(attachment certificateAttachment) *schema.Resource {
+	return &schema.Resource{
+		Create: attachment.create,
+		Read:   attachment.read,
+		Delete: attachment.delete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"certificate_id": &schema.Schema{
+				Type:         schema.TypeInt,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIntAtLeast(1),
+			},
+			attachment.IDAttribute: &schema.Schema{
+				Type:         schema.TypeInt,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIntAtLeast(1),
+			},
+		},
+	}
+}
+
+func (a certificateAttachment) create(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	certificateID := d.Get("certificate_id").(int)
+	linkedID := d.Get(a.IDAttribute).(int)
+
+	_, err := updateCertificate(certificateID, config, func(requestData map[string]interface{}) error {
+		links, _ := requestData[a.Field].([]interface{})
+		if !containsLink(links, linkedID) {
+			links = append(links, map[string]interface{}{"id": linkedID})
+		}
+		requestData[a.Field] = links
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%d/%d", certificateID, linkedID))
+
+	return a.read(d, config)
+}
+
+func (a certificateAttachment) read(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	certificate, err := getCertificateByID(d.Get("certificate_id").(int), config)
+	if err != nil {
+		return err
+	}
+
+	links, _ := certificate[a.Field].([]interface{})
+	if certificate == nil || !containsLink(links, d.Get(a.IDAttribute).(int)) {
+		d.SetId("")
+	}
+
+	return nil
+}
+
+func (a certificateAttachment) delete(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutDelete))
+	defer cancel()
+
+	certificateID := d.Get("certificate_id").(int)
+	linkedID := d.Get(a.IDAttribute).(int)
+
+	certificate, err := getCertificateByID(certificateID, config)
+	if err != nil {
+		return err
+	}
+	if certificate == nil {
+		d.SetId("")
+		return nil
+	}
+
+	_, err = updateCertificate(certificateID, config, func(requestData map[string]interface{}) error {
+		links, _ := requestData[a.Field].([]interface{})
+		kept := make([]interface{}, 0, len(links))
+		for _, link := range links {
+			if linkID(link) != linkedID {
+				kept = append(kept, link)
+			}
+		}
+		requestData[a.Field] = kept
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// containsLink reports whether links, as returned by Lemur, include the
+// object with the given ID.
+func containsLink(links []interface{}, id int) bool {
+	for _, link := range links {
+		if linkID(link) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// linkID returns the ID of a linked object, which is a float64 when read
+// from Lemur and an int when added by the provider.
+func linkID(link interface{}) int {
+	object, _ := link.(map[string]interface{})
+	switch id := object["id"].(type) {
+	case float64:
+		return int(id)
+	case int:
+		return id
+	}
+	return 0
+}
@@ -0,0 +1,121 @@
+package lemur
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestResourceLemurCertificateNotificationAttachment(t *testing.T) {
+	certificate := map[string]interface{}{
+		"id":            1,
+		"owner":         "team@example.com",
+		"notifications": []interface{}{map[string]interface{}{"id": 3}},
+		"destinations":  []interface{}{map[string]interface{}{"id": 7}},
+		"replaces":      []interface{}{map[string]interface{}{"id": 9}},
+		"description":   "issued by another team",
+	}
+	// Like Lemur, a PUT resets whatever it does not include.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/1/certificates/1" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == "PUT" {
+			certificate = map[string]interface{}{"id": 1}
+			if err := json.NewDecoder(r.Body).Decode(&certificate); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+		}
+		json.NewEncoder(w).Encode(certificate)
+	}))
+	defer server.Close()
+
+	config := Config{Host: server.URL}
+	resource := resourceLemurCertificateNotificationAttachment()
+
+	notificationIDs := func() []int {
+		ids := []int{}
+		for _, link := range certificate["notifications"].([]interface{}) {
+			ids = append(ids, linkID(link))
+		}
+		return ids
+	}
+
+	state, err := resource.Apply(&terraform.InstanceState{}, &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"certificate_id":  &terraform.ResourceAttrDiff{New: "1"},
+			"notification_id": &terraform.ResourceAttrDiff{New: "5"},
+		},
+	}, config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if state.ID != "1/5" {
+		t.Fatalf("unexpected ID: %s", state.ID)
+	}
+	if ids := notificationIDs(); !reflect.DeepEqual(ids, []int{3, 5}) {
+		t.Fatalf("unexpected notifications: %v", ids)
+	}
+	if destinations := certificate["destinations"].([]interface{}); len(destinations) != 1 || linkID(destinations[0]) != 7 {
+		t.Fatalf("expected the destinations to be kept, got %v", destinations)
+	}
+	if replaces, _ := certificate["replaces"].([]interface{}); len(replaces) != 1 || linkID(replaces[0]) != 9 {
+		t.Fatalf("expected the replacement lineage to be kept, got %v", certificate["replaces"])
+	}
+	if certificate["description"] != "issued by another team" {
+		t.Fatalf("expected the description to be kept, got %v", certificate["description"])
+	}
+
+	if _, err := resource.Apply(state, &terraform.InstanceDiff{Destroy: true}, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ids := notificationIDs(); !reflect.DeepEqual(ids, []int{3}) {
+		t.Fatalf("unexpected notifications: %v", ids)
+	}
+}
+
+func TestResourceLemurCertificateAttachment_parallel(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.addCertificate(1)["notifications"] = []interface{}{}
+
+	resource := resourceLemurCertificateNotificationAttachment()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 1; i <= 10; i++ {
+		diff := testResourceDiff(t, resource, nil, map[string]interface{}{
+			"certificate_id":  1,
+			"notification_id": i,
+		})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := resource.Apply(nil, diff, lemur.config())
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	ids := []int{}
+	for _, link := range lemur.certificates[1]["notifications"].([]interface{}) {
+		ids = append(ids, linkID(link))
+	}
+	sort.Ints(ids)
+	if !reflect.DeepEqual(ids, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}) {
+		t.Fatalf("expected every notification to be attached, got %v", ids)
+	}
+}
@@ -0,0 +1,201 @@
+package lemur
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// crlReasons are the RFC 5280 reason codes Lemur accepts when revoking.
+var crlReasons = []string{
+	"unspecified",
+	"keyCompromise",
+	"cACompromise",
+	"affiliationChanged",
+	"superseded",
+	"cessationOfOperation",
+	"certificateHold",
+	"removeFromCRL",
+	"privilegeWithdrawn",
+	"aACompromise",
+}
+
+func resourceLemurCertificateRevocation() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLemurCertificateRevocationCreate,
+		Read:   resourceLemurCertificateRevocationRead,
+		Delete: resourceLemurCertificateRevocationDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"certificate_id": &schema.Schema{
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"name", "serial"},
+				ValidateFunc:  validateIntAtLeast(1),
+			},
+			"name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"certificate_id", "serial"},
+			},
+			"serial": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"certificate_id", "name"},
+			},
+			"crl_reason": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateStringInSlice(crlReasons),
+			},
+			"comments": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"revoked": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			// When this resource revoked the certificate. Lemur does not
+			// return the revocation time, so it stays empty for a
+			// certificate which was already revoked.
+			"revoked_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// revocationCertificate finds the single certificate to revoke by ID, name
+// or serial. Anything ambiguous is an error, never a guess.
+func revocationCertificate(d *schema.ResourceData, config Config) (map[string]interface{}, error) {
+	if v, ok := d.GetOk("certificate_id"); ok {
+		certificate, err := getCertificateByID(v.(int), config)
+		if err != nil {
+			return nil, err
+		}
+		if certificate == nil {
+			return nil, fmt.Errorf("No certificate found with id %d", v.(int))
+		}
+		return certificate, nil
+	}
+
+	var key, value string
+	if v, ok := d.GetOk("name"); ok {
+		key, value = "name", v.(string)
+	} else if v, ok := d.GetOk("serial"); ok {
+		key, value = "serial", v.(string)
+	} else {
+		return nil, fmt.Errorf("One of %q must be set to revoke a certificate", []string{"certificate_id", "name", "serial"})
+	}
+
+	items, err := listItems("certificates", key+";"+value, config)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []map[string]interface{}{}
+	for _, item := range items {
+		if stringValue(item, key) == value {
+			matches = append(matches, item)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("No certificate found with %s %s", key, value)
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("%d certificates found with %s %s, use certificate_id instead", len(matches), key, value)
+	}
+
+	return matches[0], nil
+}
+
+func resourceLemurCertificateRevocationCreate(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	certificate, err := revocationCertificate(d, config)
+	if err != nil {
+		return err
+	}
+	certificateID := int(certificate["id"].(float64))
+
+	if revoked, _ := certificate["revoked"].(bool); revoked {
+		log.Printf("[WARN] Certificate %d was already revoked, revoked_at is left empty", certificateID)
+	} else {
+		url := config.Host + "/api/1/certificates/" + strconv.Itoa(certificateID) + "/revoke"
+		requestData := RevokeCertificateRequest{
+			CRLReason: d.Get("crl_reason").(string),
+			Comments:  d.Get("comments").(string),
+		}
+
+		err = config.request("PUT", url, requestData, nil)
+		if err != nil {
+			return fmt.Errorf("Error revoking certificate %d: %s", certificateID, err)
+		}
+		d.Set("revoked_at", time.Now().UTC().Format(time.RFC3339))
+	}
+
+	d.SetId(strconv.Itoa(certificateID))
+
+	return resourceLemurCertificateRevocationRead(d, config)
+}
+
+func resourceLemurCertificateRevocationRead(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	certificateID, err := strconv.Atoi(d.Id())
+	if err != nil {
+		return fmt.Errorf("Invalid certificate ID: %s", d.Id())
+	}
+
+	certificate, err := getCertificateByID(certificateID, config)
+	if err != nil {
+		return err
+	}
+	if certificate == nil {
+		d.SetId("")
+		return nil
+	}
+
+	revoked, _ := certificate["revoked"].(bool)
+	d.Set("certificate_id", certificateID)
+	d.Set("name", stringValue(certificate, "name"))
+	d.Set("serial", stringValue(certificate, "serial"))
+	d.Set("revoked", revoked)
+	d.Set("status", stringValue(certificate, "status"))
+
+	return nil
+}
+
+func resourceLemurCertificateRevocationDelete(d *schema.ResourceData, meta interface{}) error {
+	// Lemur cannot undo a revocation, so this only forgets the record.
+	log.Printf("[WARN] Certificate %s stays revoked, a revocation cannot be undone", d.Id())
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,99 @@
+package lemur
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestResourceLemurCertificateRevocation(t *testing.T) {
+	certificate := map[string]interface{}{"id": 1, "name": "compromised", "serial": "42", "status": "valid", "revoked": false}
+	var revocation RevokeCertificateRequest
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.URL.Path == "/api/1/certificates" && r.URL.Query().Get("filter") == "serial;42":
+			json.NewEncoder(w).Encode(map[string]interface{}{"items": []interface{}{certificate}, "total": 1})
+		case r.URL.Path == "/api/1/certificates/1":
+			json.NewEncoder(w).Encode(certificate)
+		case r.URL.Path == "/api/1/certificates/1/revoke" && r.Method == "PUT":
+			if err := json.NewDecoder(r.Body).Decode(&revocation); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			certificate["revoked"] = true
+			certificate["status"] = "revoked"
+			json.NewEncoder(w).Encode(certificate)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config := Config{Host: server.URL}
+	resource := resourceLemurCertificateRevocation()
+
+	state, err := resource.Apply(&terraform.InstanceState{}, &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"serial":     &terraform.ResourceAttrDiff{New: "42"},
+			"crl_reason": &terraform.ResourceAttrDiff{New: "keyCompromise"},
+		},
+	}, config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if revocation.CRLReason != "keyCompromise" {
+		t.Fatalf("unexpected crlReason: %q", revocation.CRLReason)
+	}
+	if state.ID != "1" || state.Attributes["status"] != "revoked" || state.Attributes["revoked"] != "true" {
+		t.Fatalf("unexpected state: %v", state)
+	}
+	if state.Attributes["name"] != "compromised" || state.Attributes["revoked_at"] == "" {
+		t.Fatalf("unexpected state: %v", state)
+	}
+
+	before := requests
+	if _, err := resource.Apply(state, &terraform.InstanceDiff{Destroy: true}, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if requests != before {
+		t.Fatal("expected destroy not to call Lemur")
+	}
+}
+
+func TestResourceLemurCertificateRevocation_alreadyRevoked(t *testing.T) {
+	revokeCalled := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/1/certificates/1":
+			w.Write([]byte(`{"id": 1, "name": "compromised", "serial": "42", "status": "revoked", "revoked": true}`))
+		case "/api/1/certificates/1/revoke":
+			revokeCalled = true
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "Cannot revoke certificate. Certificate has already been revoked."}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	state, err := resourceLemurCertificateRevocation().Apply(&terraform.InstanceState{}, &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"certificate_id": &terraform.ResourceAttrDiff{New: "1"},
+			"crl_reason":     &terraform.ResourceAttrDiff{New: "keyCompromise"},
+		},
+	}, Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if revokeCalled {
+		t.Fatal("expected an already revoked certificate not to be revoked again")
+	}
+	if state.Attributes["revoked"] != "true" || state.Attributes["revoked_at"] != "" {
+		t.Fatalf("expected revoked_at to stay empty, got %v", state.Attributes)
+	}
+}
@@ -0,0 +1,532 @@
+package lemur
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func testResourceConfig(t *testing.T, raw map[string]interface{}) *terraform.ResourceConfig {
+	rawConfig, err := config.NewRawConfig(raw)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return terraform.NewResourceConfig(rawConfig)
+}
+
+// testResourceDiff plans resource from raw configuration against state, as
+// terraform plan would.
+func testResourceDiff(t *testing.T, resource *schema.Resource, state *terraform.InstanceState, raw map[string]interface{}) *terraform.InstanceDiff {
+	c := testResourceConfig(t, raw)
+	if _, errs := resource.Validate(c); len(errs) > 0 {
+		t.Fatalf("invalid configuration: %v", errs)
+	}
+	diff, err := resource.Diff(state, c, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return diff
+}
+
+// testCertificateRawConfig is the smallest valid lemur_certificate
+// configuration.
+func testCertificateRawConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"name":           "example.com",
+		"authority":      "ca",
+		"owner":          "team@example.com",
+		"common_name":    "example.com",
+		"description":    "test",
+		"validity_years": 1,
+	}
+}
+
+func TestResourceLemurCertificateCreate_extensions(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.issued, lemur.issuedKey = testLemurCertificate(t, 1)
+
+	raw := testCertificateRawConfig()
+	raw["key_usage"] = []interface{}{"digital_signature", "key_encipherment"}
+	raw["basic_constraints"] = []interface{}{
+		map[string]interface{}{"ca": true, "path_length": 0},
+	}
+	raw["authority_info_access"] = true
+	raw["crl_distribution_points"] = true
+	raw["custom_extension"] = []interface{}{
+		map[string]interface{}{"oid": "1.3.6.1.4.1.11129.2.4.3", "critical": true, "value": "BQA="},
+	}
+
+	resource := resourceLemurCertificate()
+	if _, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), lemur.config()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	extensions := lemur.created["extensions"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"keyUsage": map[string]interface{}{
+			"useDigitalSignature": true,
+			"useNonRepudiation":   false,
+			"useKeyEncipherment":  true,
+			"useDataEncipherment": false,
+			"useKeyAgreement":     false,
+			"useKeyCertSign":      false,
+			"useCRLSign":          false,
+			"useEncipherOnly":     false,
+			"useDecipherOnly":     false,
+		},
+		"basicConstraints":      map[string]interface{}{"ca": true, "pathLength": float64(0)},
+		"certificateInfoAccess": map[string]interface{}{"includeAia": true},
+		"crlDistributionPoints": map[string]interface{}{"includeCrlDp": true},
+		"custom": []interface{}{
+			map[string]interface{}{"oid": "1.3.6.1.4.1.11129.2.4.3", "encoding": "b64asn1", "value": "BQA=", "isCritical": true},
+		},
+	}
+	for key, value := range expected {
+		if !reflect.DeepEqual(extensions[key], value) {
+			t.Errorf("unexpected %s: %#v", key, extensions[key])
+		}
+	}
+
+	// Without basic_constraints.path_length the path length is left out.
+	lemur.created = nil
+	delete(lemur.certificates, 1)
+	raw["basic_constraints"] = []interface{}{map[string]interface{}{"ca": false}}
+	if _, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), lemur.config()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	extensions = lemur.created["extensions"].(map[string]interface{})
+	if v := extensions["basicConstraints"]; !reflect.DeepEqual(v, map[string]interface{}{"ca": false}) {
+		t.Errorf("unexpected basicConstraints: %#v", v)
+	}
+
+	for attribute, value := range map[string]interface{}{
+		"key_usage":        []interface{}{"signing"},
+		"custom_extension": []interface{}{map[string]interface{}{"oid": "not-an-oid", "value": "BQA="}},
+	} {
+		invalid := map[string]interface{}{}
+		for k, v := range raw {
+			invalid[k] = v
+		}
+		invalid[attribute] = value
+		if _, errs := resource.Validate(testResourceConfig(t, invalid)); len(errs) == 0 {
+			t.Errorf("expected an invalid %s to be rejected", attribute)
+		}
+	}
+}
+
+func TestResourceLemurCertificateCreate_timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			// Lemur hangs past the Create timeout.
+			<-release
+			return
+		}
+		if r.URL.Path == "/api/1/certificates" {
+			w.Write([]byte(`{"items": [], "total": 0}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	raw := testCertificateRawConfig()
+	raw["timeouts"] = []map[string]interface{}{{"create": "100ms"}}
+
+	resource := resourceLemurCertificate()
+	start := time.Now()
+	_, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), Config{Host: server.URL})
+	if err == nil || !strings.Contains(err.Error(), "Timeout while waiting for a response") {
+		t.Fatalf("expected a timeout error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected Create to give up after its timeout, took %s", elapsed)
+	}
+}
+
+func TestResourceLemurCertificateDelete_inUse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/1/certificates/1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"id": 1, "endpoints": [{"name": "web-elb", "type": "elb", "dnsname": "web.example.com", "port": 443}]}`))
+	}))
+	defer server.Close()
+
+	config := Config{Host: server.URL}
+	destroy := func(id string, prevent string) error {
+		state := &terraform.InstanceState{
+			ID:         id,
+			Attributes: map[string]string{"prevent_destroy_if_in_use": prevent},
+		}
+		_, err := resourceLemurCertificate().Apply(state, &terraform.InstanceDiff{Destroy: true}, config)
+		return err
+	}
+
+	if err := destroy("1", "false"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err := destroy("1", "true")
+	if err == nil {
+		t.Fatal("expected error for a certificate in use")
+	}
+	if !strings.Contains(err.Error(), "web-elb (elb, web.example.com:443)") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := destroy("2", "true"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestGetManagedCertificate_followRotation(t *testing.T) {
+	certificates := map[string]string{
+		"/api/1/certificates/1": `{"id": 1, "active": true, "replacedBy": [{"id": 2}]}`,
+		"/api/1/certificates/2": `{"id": 2, "active": true, "replaces": [{"id": 1}], "replacedBy": [{"id": 4}, {"id": 3}]}`,
+		"/api/1/certificates/3": `{"id": 3, "active": false, "replaces": [{"id": 2}]}`,
+		"/api/1/certificates/4": `{"id": 4, "active": true, "replaces": [{"id": 2}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := certificates[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	d := resourceLemurCertificate().TestResourceData()
+	d.SetId("1")
+	d.Set("follow_rotation", true)
+
+	certificate, err := getManagedCertificate(d, Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if certificate == nil || certificate["id"].(float64) != 4 {
+		t.Fatalf("expected to follow the rotation to certificate 4, got %v", certificate)
+	}
+	if links := certificateLinks(certificate, "replaces"); len(links) != 1 || links[0] != "2" {
+		t.Fatalf("unexpected replaces: %v", links)
+	}
+
+	d.SetId("5")
+	if certificate, err := getManagedCertificate(d, Config{Host: server.URL}); err != nil || certificate != nil {
+		t.Fatalf("expected no certificate for a deleted one, got %v, %v", certificate, err)
+	}
+}
+
+func TestResourceLemurCertificateCreate_extendedKeyUsage(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.issued, lemur.issuedKey = testLemurCertificate(t, 1)
+
+	create := func(extendedKeyUsage map[string]interface{}) map[string]interface{} {
+		lemur.created = nil
+		delete(lemur.certificates, 1)
+
+		raw := testCertificateRawConfig()
+		if extendedKeyUsage != nil {
+			raw["extended_key_usage"] = []interface{}{extendedKeyUsage}
+		}
+		resource := resourceLemurCertificate()
+		if _, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), lemur.config()); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return lemur.created["extensions"].(map[string]interface{})
+	}
+
+	extensions := create(nil)
+	if _, ok := extensions["extendedKeyUsage"]; ok {
+		t.Fatalf("expected no extendedKeyUsage, got %#v", extensions["extendedKeyUsage"])
+	}
+
+	extensions = create(map[string]interface{}{"use_server_authentication": true})
+	if v, _ := extensions["extendedKeyUsage"].(map[string]interface{}); v["useServerAuthentication"] != true {
+		t.Fatalf("unexpected extendedKeyUsage: %#v", extensions["extendedKeyUsage"])
+	}
+	if _, ok := extensions["custom"]; ok {
+		t.Fatalf("expected no custom extensions, got %#v", extensions["custom"])
+	}
+
+	extensions = create(map[string]interface{}{"use_server_authentication": true, "oids": []interface{}{"1.3.6.1.4.1.311.20.2.2"}})
+	if _, ok := extensions["extendedKeyUsage"]; ok {
+		t.Fatalf("expected only the custom extension, got extendedKeyUsage %#v", extensions["extendedKeyUsage"])
+	}
+	custom, _ := extensions["custom"].([]interface{})
+	if len(custom) != 1 || custom[0].(map[string]interface{})["oid"] != "2.5.29.37" {
+		t.Fatalf("expected a custom 2.5.29.37 extension, got %#v", extensions["custom"])
+	}
+}
+
+func TestResourceLemurCertificate_extendedKeyUsageAddedByCA(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.issued, lemur.issuedKey = testLemurCertificate(t, 1)
+
+	// The CA adds client authentication to the requested server authentication.
+	template := testCertificateTemplate()
+	template.NotAfter = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	lemur.issued["body"], _ = testCertificatePEM(t, template)
+
+	raw := testCertificateRawConfig()
+	raw["extended_key_usage"] = []interface{}{map[string]interface{}{"use_server_authentication": true}}
+
+	resource := resourceLemurCertificate()
+	state, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	state, err = resource.Refresh(state, lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if diff := testResourceDiff(t, resource, state, raw); !diff.Empty() {
+		t.Fatalf("expected no changes for a usage added by the CA, got %v", diff)
+	}
+}
+
+func TestResourceLemurCertificate_dnsProvider(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.issued, lemur.issuedKey = testLemurCertificate(t, 1)
+	lemur.issued["dnsProvider"] = map[string]interface{}{"id": float64(7)}
+
+	raw := testCertificateRawConfig()
+	raw["dns_provider"] = "7"
+
+	resource := resourceLemurCertificate()
+	state, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v := lemur.created["dnsProvider"]; !reflect.DeepEqual(v, map[string]interface{}{"id": float64(7)}) {
+		t.Fatalf("unexpected dnsProvider: %#v", v)
+	}
+	if state.Attributes["dns_provider"] != "7" {
+		t.Fatalf("unexpected dns_provider: %q", state.Attributes["dns_provider"])
+	}
+
+	raw["dns_provider"] = "8"
+	if diff := testResourceDiff(t, resource, state, raw); !diff.RequiresNew() {
+		t.Fatal("expected a new DNS provider to require a new certificate")
+	}
+}
+
+func TestResourceLemurCertificate_dnsProviderUnconfigured(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.issued, lemur.issuedKey = testLemurCertificate(t, 1)
+
+	raw := testCertificateRawConfig()
+	resource := resourceLemurCertificate()
+	state, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Lemur reports the DNS provider it used although none is configured.
+	lemur.certificates[1]["dnsProvider"] = map[string]interface{}{"id": float64(7)}
+	state, err = resource.Refresh(state, lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if state.Attributes["dns_provider"] != "7" {
+		t.Fatalf("unexpected dns_provider: %q", state.Attributes["dns_provider"])
+	}
+	if diff := testResourceDiff(t, resource, state, raw); !diff.Empty() {
+		t.Fatalf("expected no changes without dns_provider in the configuration, got %v", diff)
+	}
+}
+
+func TestResourceLemurCertificateUpdate_rotation(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.issued, lemur.issuedKey = testLemurCertificate(t, 1)
+	lemur.issued["rotation"] = true
+	lemur.issued["notifications"] = []interface{}{map[string]interface{}{"id": float64(4)}}
+
+	resource := resourceLemurCertificate()
+	raw := testCertificateRawConfig()
+	state, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	raw["rotation"] = false
+	raw["rotation_policy"] = "short"
+	diff := testResourceDiff(t, resource, state, raw)
+	if diff.RequiresNew() {
+		t.Fatal("expected rotation changes to be applied in place")
+	}
+	state, err = resource.Apply(state, diff, lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(lemur.updates) != 1 {
+		t.Fatalf("expected one update, got %d", len(lemur.updates))
+	}
+	update := lemur.updates[0]
+	if update["rotation"] != false || !reflect.DeepEqual(update["rotationPolicy"], map[string]interface{}{"name": "short"}) {
+		t.Fatalf("unexpected rotation in update: %#v", update)
+	}
+	if update["owner"] != "team@example.com" || !reflect.DeepEqual(update["notifications"], []interface{}{map[string]interface{}{"id": float64(4)}}) {
+		t.Fatalf("expected the other fields to be kept, got %#v", update)
+	}
+	if state.Attributes["rotation"] != "false" || state.Attributes["rotation_policy"] != "short" {
+		t.Fatalf("unexpected state: %v", state.Attributes)
+	}
+}
+
+func TestResourceLemurCertificate_followRotation(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.issued, lemur.issuedKey = testLemurCertificate(t, 1)
+
+	resource := resourceLemurCertificate()
+	raw := testCertificateRawConfig()
+	raw["follow_rotation"] = true
+	state, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// A replacement which was revoked right away is not followed, and the
+	// still valid certificate is kept as it is.
+	lemur.addCertificate(2)["revoked"] = true
+	lemur.certificates[1]["replacedBy"] = []interface{}{map[string]interface{}{"id": float64(2)}}
+	refreshed, err := resource.Refresh(state, lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if refreshed.ID != "1" || refreshed.Attributes["replacement_reason"] != "" || refreshed.Attributes["rotated_from"] != "" {
+		t.Fatalf("expected certificate 1 to be kept, got %v", refreshed)
+	}
+
+	// The same certificate expiring without a valid replacement is replaced
+	// for the right reason.
+	lemur.certificates[1]["status"] = "expired"
+	refreshed, err = resource.Refresh(state, lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v := refreshed.Attributes["replacement_reason"]; v != "certificate 1 is expired" {
+		t.Fatalf("unexpected replacement_reason: %q", v)
+	}
+
+	// A valid replacement is followed and the previous ID shows up in the
+	// plan as an in-place update.
+	replacement := lemur.addCertificate(3)
+	replacement["replaces"] = []interface{}{map[string]interface{}{"id": float64(1)}}
+	lemur.certificates[1]["replacedBy"] = []interface{}{
+		map[string]interface{}{"id": float64(2)},
+		map[string]interface{}{"id": float64(3)},
+	}
+	refreshed, err = resource.Refresh(state, lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if refreshed.ID != "3" || refreshed.Attributes["rotated_from"] != "1" || refreshed.Attributes["replaces.0"] != "1" {
+		t.Fatalf("expected to move to certificate 3, got %v", refreshed)
+	}
+	if refreshed.Attributes["pem_public_certificate"] != replacement["body"] {
+		t.Fatal("expected the PEMs of the replacement")
+	}
+
+	diff := testResourceDiff(t, resource, refreshed, raw)
+	if diff.RequiresNew() {
+		t.Fatal("expected following the rotation not to replace the resource")
+	}
+	if attr, ok := diff.Attributes["rotated_from"]; !ok || attr.Old != "1" || attr.New != "" {
+		t.Fatalf("expected the plan to show rotated_from, got %v", diff)
+	}
+
+	applied, err := resource.Apply(refreshed, diff, lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if applied.ID != "3" || applied.Attributes["rotated_from"] != "" {
+		t.Fatalf("unexpected state after apply: %v", applied)
+	}
+	if diff := testResourceDiff(t, resource, applied, raw); !diff.Empty() {
+		t.Fatalf("expected no further changes, got %v", diff)
+	}
+
+	raw["rotated_from"] = "1"
+	if _, errs := resource.Validate(testResourceConfig(t, raw)); len(errs) == 0 {
+		t.Fatal("expected rotated_from not to be configurable")
+	}
+}
+
+func TestResourceLemurCertificate_replaceInvalid(t *testing.T) {
+	lemur := newTestLemur(t)
+	defer lemur.Close()
+	lemur.issued, lemur.issuedKey = testLemurCertificate(t, 1)
+
+	resource := resourceLemurCertificate()
+	raw := testCertificateRawConfig()
+	state, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if diff := testResourceDiff(t, resource, state, raw); !diff.Empty() {
+		t.Fatalf("expected no changes for a valid certificate, got %v", diff)
+	}
+
+	lemur.certificates[1]["revoked"] = true
+	refreshed, err := resource.Refresh(state, lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v := refreshed.Attributes["replacement_reason"]; v != "certificate 1 is revoked" {
+		t.Fatalf("unexpected replacement_reason: %q", v)
+	}
+
+	diff := testResourceDiff(t, resource, refreshed, raw)
+	if !diff.RequiresNew() {
+		t.Fatalf("expected a revoked certificate to be replaced, got %v", diff)
+	}
+	if attr := diff.Attributes["replacement_reason"]; attr == nil || !attr.RequiresNew || attr.Old != "certificate 1 is revoked" {
+		t.Fatalf("expected the plan to show why, got %v", diff)
+	}
+
+	state.Attributes["invalid_certificate_action"] = "remove"
+	refreshed, err = resource.Refresh(state, lemur.config())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if refreshed != nil && refreshed.ID != "" {
+		t.Fatalf("expected the certificate to be removed from state, got %v", refreshed)
+	}
+}
+
+func TestResourceLemurCertificateDiff_privateKeyHandling(t *testing.T) {
+	resource := resourceLemurCertificate()
+	raw := testCertificateRawConfig()
+	raw["private_key_handling"] = "omit"
+	raw["export_formats"] = []interface{}{"pkcs12", "der"}
+
+	_, err := resource.Diff(nil, testResourceConfig(t, raw), nil)
+	if err == nil || !strings.Contains(err.Error(), "pkcs12") {
+		t.Fatalf("expected the plan to reject pkcs12, got: %v", err)
+	}
+
+	raw["export_formats"] = []interface{}{"der"}
+	testResourceDiff(t, resource, nil, raw)
+}
@@ -0,0 +1,144 @@
+package lemur
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceLemurDNSProvider() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLemurDNSProviderCreate,
+		Read:   resourceLemurDNSProviderRead,
+		Delete: resourceLemurDNSProviderDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"provider_type": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+				ValidateFunc: validateStringInSlice([]string{
+					"route53",
+					"cloudflare",
+					"dyn",
+					"powerdns",
+					"ultradns",
+					"nsone",
+				}),
+			},
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			// Lemur never returns the credentials, so they are only sent on
+			// create.
+			"credentials": &schema.Schema{
+				Type:      schema.TypeMap,
+				Optional:  true,
+				ForceNew:  true,
+				Sensitive: true,
+			},
+		},
+	}
+}
+
+func resourceLemurDNSProviderCreate(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	url := config.Host + "/api/1/dns_providers"
+	requestData := CreateDNSProviderRequest{
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+		ProviderType: CreateDNSProviderTypeRequest{
+			Name:         d.Get("provider_type").(string),
+			Requirements: []CreateDNSProviderOption{},
+		},
+	}
+
+	credentials := d.Get("credentials").(map[string]interface{})
+	names := make([]string, 0, len(credentials))
+	for name := range credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		requestData.ProviderType.Requirements = append(requestData.ProviderType.Requirements, CreateDNSProviderOption{
+			Name:  name,
+			Value: credentials[name].(string),
+		})
+	}
+
+	var dnsProvider map[string]interface{}
+	err := config.request("POST", url, requestData, &dnsProvider)
+	if err != nil {
+		return err
+	}
+
+	id, ok := dnsProvider["id"].(float64)
+	if !ok {
+		return fmt.Errorf("Lemur did not return the ID of DNS provider %s", requestData.Name)
+	}
+	d.SetId(strconv.Itoa(int(id)))
+
+	return resourceLemurDNSProviderRead(d, config)
+}
+
+func resourceLemurDNSProviderRead(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	url := config.Host + "/api/1/dns_providers/" + d.Id()
+
+	var dnsProvider map[string]interface{}
+	err := config.request("GET", url, nil, &dnsProvider)
+	if isNotFound(err) {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	d.Set("name", stringValue(dnsProvider, "name"))
+	d.Set("description", stringValue(dnsProvider, "description"))
+
+	switch providerType := dnsProvider["providerType"].(type) {
+	case string:
+		d.Set("provider_type", providerType)
+	case map[string]interface{}:
+		d.Set("provider_type", stringValue(providerType, "name"))
+	}
+
+	return nil
+}
+
+func resourceLemurDNSProviderDelete(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutDelete))
+	defer cancel()
+
+	url := config.Host + "/api/1/dns_providers/" + d.Id()
+
+	err := config.request("DELETE", url, nil, nil)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
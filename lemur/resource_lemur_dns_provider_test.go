@@ -0,0 +1,84 @@
+package lemur
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestResourceLemurDNSProvider(t *testing.T) {
+	var created map[string]interface{}
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/1/dns_providers" && r.Method == "POST":
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			w.Write([]byte(`{"id": 7}`))
+		case r.URL.Path == "/api/1/dns_providers/7" && r.Method == "GET" && !deleted:
+			w.Write([]byte(`{"id": 7, "name": "route53-prod", "description": "Production zones", "providerType": "route53"}`))
+		case r.URL.Path == "/api/1/dns_providers/7" && r.Method == "DELETE":
+			deleted = true
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	config := Config{Host: server.URL}
+	resource := resourceLemurDNSProvider()
+
+	raw := map[string]interface{}{
+		"name":          "route53-prod",
+		"description":   "Production zones",
+		"provider_type": "route53",
+		"credentials": map[string]interface{}{
+			"account_id": "123456789012",
+			"role_name":  "lemur-dns",
+		},
+	}
+	state, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"name":        "route53-prod",
+		"description": "Production zones",
+		"providerType": map[string]interface{}{
+			"name": "route53",
+			"requirements": []interface{}{
+				map[string]interface{}{"name": "account_id", "value": "123456789012"},
+				map[string]interface{}{"name": "role_name", "value": "lemur-dns"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(created, expected) {
+		t.Fatalf("unexpected request: %#v", created)
+	}
+	if state.ID != "7" || state.Attributes["provider_type"] != "route53" {
+		t.Fatalf("unexpected state: %v", state)
+	}
+
+	if _, err := resource.Apply(state, &terraform.InstanceDiff{Destroy: true}, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !deleted {
+		t.Fatal("expected the DNS provider to be deleted")
+	}
+
+	refreshed, err := resource.Refresh(state, config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if refreshed != nil && refreshed.ID != "" {
+		t.Fatalf("expected a deleted DNS provider to be removed from state, got %v", refreshed)
+	}
+}
@@ -0,0 +1,120 @@
+package lemur
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceLemurRotationPolicy() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceLemurRotationPolicyCreate,
+		Read:   resourceLemurRotationPolicyRead,
+		Update: resourceLemurRotationPolicyUpdate,
+		Delete: resourceLemurRotationPolicyDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// The number of days before expiry at which Lemur reissues.
+			"days": &schema.Schema{
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validateIntAtLeast(1),
+			},
+		},
+	}
+}
+
+func resourceLemurRotationPolicyCreate(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	url := config.Host + "/api/1/policies"
+	requestData := RotationPolicyRequest{
+		Name: d.Get("name").(string),
+		Days: d.Get("days").(int),
+	}
+
+	var policy map[string]interface{}
+	err := config.request("POST", url, requestData, &policy)
+	if err != nil {
+		return err
+	}
+
+	id, ok := policy["id"].(float64)
+	if !ok {
+		return fmt.Errorf("Lemur did not return the ID of rotation policy %s", requestData.Name)
+	}
+	d.SetId(strconv.Itoa(int(id)))
+
+	return resourceLemurRotationPolicyRead(d, config)
+}
+
+func resourceLemurRotationPolicyRead(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	url := config.Host + "/api/1/policies/" + d.Id()
+
+	var policy map[string]interface{}
+	err := config.request("GET", url, nil, &policy)
+	if isNotFound(err) {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	d.Set("name", stringValue(policy, "name"))
+	if days, ok := policy["days"].(float64); ok {
+		d.Set("days", int(days))
+	}
+
+	return nil
+}
+
+func resourceLemurRotationPolicyUpdate(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	url := config.Host + "/api/1/policies/" + d.Id()
+	requestData := RotationPolicyRequest{
+		Name: d.Get("name").(string),
+		Days: d.Get("days").(int),
+	}
+
+	err := config.request("PUT", url, requestData, nil)
+	if err != nil {
+		return err
+	}
+
+	return resourceLemurRotationPolicyRead(d, config)
+}
+
+func resourceLemurRotationPolicyDelete(d *schema.ResourceData, meta interface{}) error {
+	config, cancel := meta.(Config).withTimeout(d.Timeout(schema.TimeoutDelete))
+	defer cancel()
+
+	url := config.Host + "/api/1/policies/" + d.Id()
+
+	err := config.request("DELETE", url, nil, nil)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,80 @@
+package lemur
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestResourceLemurRotationPolicy(t *testing.T) {
+	var policy map[string]interface{}
+	requests := []map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		switch {
+		case r.URL.Path == "/api/1/policies" && r.Method == "POST":
+			requests = append(requests, body)
+			policy = map[string]interface{}{"id": 3, "name": body["name"], "days": body["days"]}
+		case r.URL.Path == "/api/1/policies/3" && r.Method == "PUT" && policy != nil:
+			requests = append(requests, body)
+			policy["name"], policy["days"] = body["name"], body["days"]
+		case r.URL.Path == "/api/1/policies/3" && r.Method == "DELETE" && policy != nil:
+			policy = nil
+			w.Write([]byte(`{}`))
+			return
+		case r.URL.Path == "/api/1/policies/3" && r.Method == "GET" && policy != nil:
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "not found"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(policy)
+	}))
+	defer server.Close()
+
+	config := Config{Host: server.URL}
+	resource := resourceLemurRotationPolicy()
+
+	raw := map[string]interface{}{"name": "short", "days": 30}
+	state, err := resource.Apply(nil, testResourceDiff(t, resource, nil, raw), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if state.ID != "3" || state.Attributes["days"] != "30" {
+		t.Fatalf("unexpected state: %v", state)
+	}
+
+	raw["days"] = 14
+	state, err = resource.Apply(state, testResourceDiff(t, resource, state, raw), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if state.Attributes["days"] != "14" {
+		t.Fatalf("unexpected state: %v", state)
+	}
+
+	expected := []map[string]interface{}{
+		{"name": "short", "days": float64(30)},
+		{"name": "short", "days": float64(14)},
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Fatalf("unexpected requests: %#v", requests)
+	}
+
+	if _, err := resource.Apply(state, &terraform.InstanceDiff{Destroy: true}, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	refreshed, err := resource.Refresh(state, config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if refreshed != nil && refreshed.ID != "" {
+		t.Fatalf("expected a deleted policy to be removed from state, got %v", refreshed)
+	}
+}
@@ -0,0 +1,285 @@
+package lemur
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// The truststores in this file are built deterministically: the same
+// certificates, aliases and passphrase always give the same bytes, so an
+// unchanged set of authorities never shows up as a diff.
+
+var (
+	oidSHA1                 = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidPKCS12CertBag        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidPKCS9X509Certificate = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidPKCS9FriendlyName    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidJavaTrustedKeyUsage  = asn1.ObjectIdentifier{2, 16, 840, 1, 113894, 746875, 1, 1}
+	oidAnyExtendedKeyUsage  = asn1.ObjectIdentifier{2, 5, 29, 37, 0}
+)
+
+const (
+	jksMagic                 = 0xfeedfeed
+	jksVersion               = 2
+	jksTrustedCertificateTag = 2
+	jksDigestWhitener        = "Mighty Aphrodite"
+
+	pkcs12Version            = 3
+	pkcs12MACIterations      = 2048
+	pkcs12MACKeyDerivationID = 3
+	pkcs12MACSaltLength      = 8
+
+	// encoding/asn1 only names this tag in newer Go releases.
+	asn1TagBMPString = 30
+)
+
+type pkcs12Attribute struct {
+	ID     asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type pkcs12SafeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12CertBag struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+type pkcs12DigestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type pkcs12MacData struct {
+	Mac        pkcs12DigestInfo
+	MacSalt    []byte
+	Iterations int
+}
+
+type pkcs12PFX struct {
+	Version  int
+	AuthSafe pkcs7ContentInfo
+	MacData  pkcs12MacData
+}
+
+// truststoreAliases derives a unique, lower case alias for every
+// certificate from its common name. Keystores lower case aliases anyway, so
+// doing it here keeps JKS and PKCS#12 consistent.
+func truststoreAliases(certs []*x509.Certificate) []string {
+	aliases := make([]string, 0, len(certs))
+	used := map[string]bool{}
+	for _, cert := range certs {
+		base := strings.ToLower(cert.Subject.CommonName)
+		if base == "" {
+			_, sha256Fingerprint := certificateFingerprints(cert)
+			base = sha256Fingerprint
+		}
+
+		alias := base
+		for i := 2; used[alias]; i++ {
+			alias = base + "-" + strconv.Itoa(i)
+		}
+		used[alias] = true
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// encodeJKSTruststore builds a Java KeyStore holding only trusted
+// certificate entries.
+func encodeJKSTruststore(certs []*x509.Certificate, aliases []string, password string) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint32(jksMagic))
+	binary.Write(&buf, binary.BigEndian, uint32(jksVersion))
+	binary.Write(&buf, binary.BigEndian, uint32(len(certs)))
+
+	for i, cert := range certs {
+		binary.Write(&buf, binary.BigEndian, uint32(jksTrustedCertificateTag))
+		writeJKSString(&buf, aliases[i])
+		// The creation date is taken from the certificate so that it does
+		// not change between runs.
+		binary.Write(&buf, binary.BigEndian, cert.NotBefore.UnixNano()/1e6)
+		writeJKSString(&buf, "X.509")
+		binary.Write(&buf, binary.BigEndian, uint32(len(cert.Raw)))
+		buf.Write(cert.Raw)
+	}
+
+	digest := sha1.New()
+	digest.Write(bmpString(password, false))
+	digest.Write([]byte(jksDigestWhitener))
+	digest.Write(buf.Bytes())
+	buf.Write(digest.Sum(nil))
+
+	return buf.Bytes()
+}
+
+func writeJKSString(buf *bytes.Buffer, s string) {
+	binary.Write(buf, binary.BigEndian, uint16(len(s)))
+	buf.WriteString(s)
+}
+
+// encodePKCS12Truststore builds a PKCS#12 file holding only certificate
+// bags, marked as trusted for Java, and protected by a SHA-1 HMAC.
+func encodePKCS12Truststore(certs []*x509.Certificate, aliases []string, password string) ([]byte, error) {
+	bags := make([]pkcs12SafeBag, 0, len(certs))
+	for i, cert := range certs {
+		certValue, err := asn1.Marshal(cert.Raw)
+		if err != nil {
+			return nil, err
+		}
+		certBag, err := asn1.Marshal(pkcs12CertBag{
+			ID:    oidPKCS9X509Certificate,
+			Value: contextSpecificValue(certValue),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		friendlyName, err := asn1.Marshal(asn1.RawValue{Tag: asn1TagBMPString, Bytes: bmpString(aliases[i], false)})
+		if err != nil {
+			return nil, err
+		}
+		trustedUsage, err := asn1.Marshal(oidAnyExtendedKeyUsage)
+		if err != nil {
+			return nil, err
+		}
+
+		bags = append(bags, pkcs12SafeBag{
+			ID:    oidPKCS12CertBag,
+			Value: contextSpecificValue(certBag),
+			Attributes: []pkcs12Attribute{
+				{ID: oidPKCS9FriendlyName, Values: setValue(friendlyName)},
+				{ID: oidJavaTrustedKeyUsage, Values: setValue(trustedUsage)},
+			},
+		})
+	}
+
+	safeContents, err := asn1.Marshal(bags)
+	if err != nil {
+		return nil, err
+	}
+	safeContentsData, err := pkcs12DataContentInfo(safeContents)
+	if err != nil {
+		return nil, err
+	}
+
+	authSafe, err := asn1.Marshal([]pkcs7ContentInfo{safeContentsData})
+	if err != nil {
+		return nil, err
+	}
+	authSafeData, err := pkcs12DataContentInfo(authSafe)
+	if err != nil {
+		return nil, err
+	}
+
+	// The MAC only protects integrity, so a salt derived from the content
+	// keeps the output deterministic without weakening anything secret.
+	contentSum := sha256.Sum256(authSafe)
+	salt := contentSum[:pkcs12MACSaltLength]
+	key := pkcs12KeyDerivation(pkcs12MACKeyDerivationID, bmpString(password, true), salt, pkcs12MACIterations, sha1.Size)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(authSafe)
+
+	return asn1.Marshal(pkcs12PFX{
+		Version:  pkcs12Version,
+		AuthSafe: authSafeData,
+		MacData: pkcs12MacData{
+			Mac: pkcs12DigestInfo{
+				Algorithm: pkix.AlgorithmIdentifier{
+					Algorithm:  oidSHA1,
+					Parameters: asn1.RawValue{Tag: asn1.TagNull},
+				},
+				Digest: mac.Sum(nil),
+			},
+			MacSalt:    salt,
+			Iterations: pkcs12MACIterations,
+		},
+	})
+}
+
+func pkcs12DataContentInfo(data []byte) (pkcs7ContentInfo, error) {
+	content, err := asn1.Marshal(data)
+	if err != nil {
+		return pkcs7ContentInfo{}, err
+	}
+	return pkcs7ContentInfo{ContentType: oidPKCS7Data, Content: contextSpecificValue(content)}, nil
+}
+
+func contextSpecificValue(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+func setValue(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: der}
+}
+
+// bmpString encodes s as UTF-16BE, optionally with the two byte terminator
+// PKCS#12 expects on passwords.
+func bmpString(s string, terminate bool) []byte {
+	var buf []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		buf = append(buf, byte(c>>8), byte(c))
+	}
+	if terminate {
+		buf = append(buf, 0, 0)
+	}
+	return buf
+}
+
+// pkcs12KeyDerivation implements the SHA-1 key derivation of RFC 7292,
+// appendix B.2.
+func pkcs12KeyDerivation(id byte, password []byte, salt []byte, iterations int, size int) []byte {
+	const v = 64
+
+	fill := func(in []byte) []byte {
+		if len(in) == 0 {
+			return nil
+		}
+		out := make([]byte, v*((len(in)+v-1)/v))
+		for i := range out {
+			out[i] = in[i%len(in)]
+		}
+		return out
+	}
+
+	d := bytes.Repeat([]byte{id}, v)
+	i := append(fill(salt), fill(password)...)
+
+	var key []byte
+	for len(key) < size {
+		h := sha1.New()
+		h.Write(d)
+		h.Write(i)
+		a := h.Sum(nil)
+		for r := 1; r < iterations; r++ {
+			sum := sha1.Sum(a)
+			a = sum[:]
+		}
+		key = append(key, a...)
+
+		b := fill(a)
+		for j := 0; j < len(i); j += v {
+			carry := 1
+			for k := v - 1; k >= 0; k-- {
+				sum := int(i[j+k]) + int(b[k]) + carry
+				i[j+k] = byte(sum)
+				carry = sum >> 8
+			}
+		}
+	}
+
+	return key[:size]
+}
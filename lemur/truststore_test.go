@@ -0,0 +1,104 @@
+package lemur
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"testing"
+)
+
+func testTruststoreCertificates(t *testing.T) []*x509.Certificate {
+	root, rootKey := testAuthorityCertificate(t, "Root CA", 1, nil, nil)
+	intermediate, _ := testAuthorityCertificate(t, "Root CA", 2, root, rootKey)
+	return []*x509.Certificate{root, intermediate}
+}
+
+func TestTruststoreAliases(t *testing.T) {
+	aliases := truststoreAliases(testTruststoreCertificates(t))
+	if len(aliases) != 2 || aliases[0] != "root ca" || aliases[1] != "root ca-2" {
+		t.Fatalf("unexpected aliases: %v", aliases)
+	}
+}
+
+func TestEncodeJKSTruststore(t *testing.T) {
+	certs := testTruststoreCertificates(t)
+	aliases := truststoreAliases(certs)
+
+	data := encodeJKSTruststore(certs, aliases, "changeit")
+	if !bytes.Equal(data, encodeJKSTruststore(certs, aliases, "changeit")) {
+		t.Fatal("expected the JKS truststore to be deterministic")
+	}
+
+	if magic := binary.BigEndian.Uint32(data); magic != jksMagic {
+		t.Fatalf("unexpected magic: %x", magic)
+	}
+	if count := binary.BigEndian.Uint32(data[8:]); count != 2 {
+		t.Fatalf("expected 2 entries, got %d", count)
+	}
+
+	body, sum := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	digest := sha1.New()
+	digest.Write([]byte{0, 'c', 0, 'h', 0, 'a', 0, 'n', 0, 'g', 0, 'e', 0, 'i', 0, 't'})
+	digest.Write([]byte("Mighty Aphrodite"))
+	digest.Write(body)
+	if !bytes.Equal(sum, digest.Sum(nil)) {
+		t.Fatal("JKS integrity digest does not match")
+	}
+}
+
+func TestEncodePKCS12Truststore(t *testing.T) {
+	certs := testTruststoreCertificates(t)
+	aliases := truststoreAliases(certs)
+
+	data, err := encodePKCS12Truststore(certs, aliases, "changeit")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	again, err := encodePKCS12Truststore(certs, aliases, "changeit")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Fatal("expected the PKCS#12 truststore to be deterministic")
+	}
+
+	var pfx pkcs12PFX
+	if _, err := asn1.Unmarshal(data, &pfx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var authSafe []byte
+	if _, err := asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authSafe); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	key := pkcs12KeyDerivation(pkcs12MACKeyDerivationID, bmpString("changeit", true), pfx.MacData.MacSalt, pfx.MacData.Iterations, sha1.Size)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(authSafe)
+	if !bytes.Equal(mac.Sum(nil), pfx.MacData.Mac.Digest) {
+		t.Fatal("PKCS#12 MAC does not match")
+	}
+
+	for _, cert := range certs {
+		if !bytes.Contains(authSafe, cert.Raw) {
+			t.Fatalf("certificate %s missing from the truststore", cert.SerialNumber)
+		}
+	}
+}
+
+func TestPKCS12KeyDerivation(t *testing.T) {
+	// Test vectors from golang.org/x/crypto/pkcs12.
+	key := pkcs12KeyDerivation(1, bmpString("sesame", true), bytes.Repeat([]byte{0xff}, 8), 2048, 24)
+	expected := []byte("\x7c\xd9\xfd\x3e\x2b\x3b\xe7\x69\x1a\x44\xe3\xbe\xf0\xf9\xea\x0f\xb9\xb8\x97\xd4\xe3\x25\xd9\xd1")
+	if !bytes.Equal(key, expected) {
+		t.Fatalf("unexpected key: %x", key)
+	}
+
+	key = pkcs12KeyDerivation(1, []byte("\x00\x00"), []byte("\xf3\x7e\x05\xb5\x18\x32\x4b\x4b"), 2048, 24)
+	expected = []byte("\x00\xf7\x59\xff\x47\xd1\x4d\xd0\x36\x65\xd5\x94\x3c\xb3\xc4\xa3\x9a\x25\x55\xc0\x2a\xed\x66\xe1")
+	if !bytes.Equal(key, expected) {
+		t.Fatalf("unexpected key: %x", key)
+	}
+}
@@ -0,0 +1,140 @@
+package lemur
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func validateDuration(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a duration such as \"30s\" or \"5m\": %s", k, err))
+	}
+	return
+}
+
+func validateBool(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := strconv.ParseBool(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be either \"true\" or \"false\", got: %s", k, v.(string)))
+	}
+	return
+}
+
+func validateRegexp(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := regexp.Compile(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q is not a valid regular expression: %s", k, err))
+	}
+	return
+}
+
+func validatePGPKey(v interface{}, k string) (ws []string, errors []error) {
+	key := v.(string)
+	if key == "" || strings.HasPrefix(key, "keybase:") {
+		return
+	}
+	if _, err := parsePGPKey(key); err != nil {
+		errors = append(errors, fmt.Errorf("%q is not a valid PGP public key: %s", k, err))
+	}
+	return
+}
+
+func validateIntAtLeast(min int) schema.SchemaValidateFunc {
+	return func(v interface{}, k string) (ws []string, errors []error) {
+		if v.(int) < min {
+			errors = append(errors, fmt.Errorf("%q must be at least %d, got: %d", k, min, v.(int)))
+		}
+		return
+	}
+}
+
+func validateStringInSlice(valid []string) schema.SchemaValidateFunc {
+	return func(v interface{}, k string) (ws []string, errors []error) {
+		value := v.(string)
+		for _, s := range valid {
+			if value == s {
+				return
+			}
+		}
+		errors = append(errors, fmt.Errorf("%q must be one of %q, got: %s", k, valid, value))
+		return
+	}
+}
+
+func validateOID(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	parts := strings.Split(value, ".")
+	if len(parts) < 2 {
+		errors = append(errors, fmt.Errorf("%q must be a dotted object identifier such as 1.3.6.1.4.1.11129.2.4.2, got: %s", k, value))
+		return
+	}
+	for _, part := range parts {
+		if _, err := strconv.ParseUint(part, 10, 32); err != nil {
+			errors = append(errors, fmt.Errorf("%q must be a dotted object identifier such as 1.3.6.1.4.1.11129.2.4.2, got: %s", k, value))
+			return
+		}
+	}
+	return
+}
+
+func validateBase64(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := base64.StdEncoding.DecodeString(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be base64 encoded: %s", k, err))
+	}
+	return
+}
+
+// sanNameTypes lists the subject alternative name types Lemur understands.
+var sanNameTypes = []string{
+	"DNSName",
+	"IPAddress",
+	"uniformResourceIdentifier",
+	"rfc822Name",
+	"directoryName",
+}
+
+var hostnameRegexp = regexp.MustCompile(`^(\*\.)?([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// validateSAN checks the syntax of a subject alternative name value against
+// its type.
+func validateSAN(nameType string, value string) error {
+	switch nameType {
+	case "DNSName":
+		if len(value) > 253 || !hostnameRegexp.MatchString(value) {
+			return fmt.Errorf("SAN %q is not a valid hostname", value)
+		}
+	case "IPAddress":
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return fmt.Errorf("SAN %q is not a valid IP address", value)
+		}
+		if ip.String() != value {
+			return fmt.Errorf("SAN %q must be written in its canonical form %q", value, ip.String())
+		}
+	case "uniformResourceIdentifier":
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" {
+			return fmt.Errorf("SAN %q is not a valid absolute URI", value)
+		}
+	case "rfc822Name":
+		address, err := mail.ParseAddress(value)
+		if err != nil || address.Address != value {
+			return fmt.Errorf("SAN %q is not a valid email address", value)
+		}
+	case "directoryName":
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("SAN of type directoryName must not be empty")
+		}
+	default:
+		return fmt.Errorf("SAN type %q must be one of %q", nameType, sanNameTypes)
+	}
+
+	return nil
+}
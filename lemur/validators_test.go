@@ -0,0 +1,38 @@
+package lemur
+
+import (
+	"testing"
+)
+
+func TestValidateSAN(t *testing.T) {
+	cases := []struct {
+		Type  string
+		Value string
+		Valid bool
+	}{
+		{"DNSName", "example.com", true},
+		{"DNSName", "*.example.com", true},
+		{"DNSName", "exa mple.com", false},
+		{"DNSName", "-example.com", false},
+		{"IPAddress", "10.0.0.1", true},
+		{"IPAddress", "2001:db8::1", true},
+		{"IPAddress", "2001:DB8::1", false},
+		{"IPAddress", "example.com", false},
+		{"uniformResourceIdentifier", "spiffe://example.com/service", true},
+		{"uniformResourceIdentifier", "example.com/service", false},
+		{"rfc822Name", "security@example.com", true},
+		{"rfc822Name", "Security <security@example.com>", false},
+		{"directoryName", "CN=example,O=Example", true},
+		{"dnsName", "example.com", false},
+	}
+
+	for _, tc := range cases {
+		err := validateSAN(tc.Type, tc.Value)
+		if tc.Valid && err != nil {
+			t.Errorf("%s %q: unexpected error: %s", tc.Type, tc.Value, err)
+		}
+		if !tc.Valid && err == nil {
+			t.Errorf("%s %q: expected an error", tc.Type, tc.Value)
+		}
+	}
+}